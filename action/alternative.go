@@ -0,0 +1,66 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Alternative registers a package as a candidate for a link several packages may provide,
+// the installed candidate with the highest priority or the one selected by an admin owns the link
+type Alternative struct {
+	Link     string `json:"link" hcl:"link,label"`
+	Target   string `json:"target" hcl:"target"`
+	Priority int    `json:"priority" hcl:"priority,optional"`
+}
+
+func NewAlternative() *Alternative {
+	return &Alternative{}
+}
+
+func (a *Alternative) Key() string {
+	return a.Link
+}
+
+func (a *Alternative) Type() string {
+	return "Alternative"
+}
+
+func (a *Alternative) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(a.Type()),
+		fmt.Sprint(a.Priority),
+		a.Target,
+		a.Link,
+	}, "|")
+}
+
+func (a *Alternative) Id() string {
+	return fmt.Sprint(a.Type(), ".", a.Key())
+}
+
+func (a *Alternative) Condition() *bool {
+	return nil
+}
+
+func (a *Alternative) MayFail() bool {
+	return false
+}
+
+func (a *Alternative) IsValid() bool {
+	if !SafePath(a.Link) || !SafePath(a.Target) {
+		return false
+	}
+
+	return path.Clean(a.Link) != path.Clean(a.Target)
+}
@@ -20,6 +20,9 @@ type Dir struct {
 	Owner string `json:"owner" hcl:"owner,optional"`
 	Group string `json:"group" hcl:"group,optional"`
 	Mode  string `json:"mode" hcl:"mode,optional"`
+
+	// POSIX ACL entries in setfacl form, default: entries are inherited by new children
+	Acl []string `json:"acl,omitempty" hcl:"acl,optional"`
 }
 
 func NewDir() *Dir {
@@ -0,0 +1,65 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Divert redirects the copy of a path any other package installs to a new location, leaving
+// the path free for the diverting package to provide its own file
+type Divert struct {
+	Path string `json:"path" hcl:"path,label"`
+	To   string `json:"to" hcl:"to"`
+}
+
+func NewDivert() *Divert {
+	return &Divert{}
+}
+
+func (d *Divert) Key() string {
+	return d.Path
+}
+
+func (d *Divert) Type() string {
+	return "Divert"
+}
+
+func (d *Divert) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(d.Type()),
+		"",
+		d.To,
+		d.Path,
+	}, "|")
+}
+
+func (d *Divert) Id() string {
+	return fmt.Sprint(d.Type(), ".", d.Key())
+}
+
+func (d *Divert) Condition() *bool {
+	return nil
+}
+
+func (d *Divert) MayFail() bool {
+	return false
+}
+
+func (d *Divert) IsValid() bool {
+	if !SafePath(d.Path) || !SafePath(d.To) {
+		return false
+	}
+
+	return path.Clean(d.Path) != path.Clean(d.To)
+}
@@ -21,6 +21,15 @@ type File struct {
 	Group string `json:"group" hcl:"group,optional"`
 	Mode  string `json:"mode" hcl:"mode,optional"`
 
+	// Config files keep local modifications across upgrade and removal
+	Config bool `json:"config,omitempty" hcl:"config,optional"`
+
+	// Extended attributes such as security.capability, values are base64 encoded
+	Xattrs map[string]string `json:"xattrs,omitempty" hcl:"xattrs,optional"`
+
+	// POSIX ACL entries in setfacl form, for example group:adm:r--
+	Acl []string `json:"acl,omitempty" hcl:"acl,optional"`
+
 	Digest string `json:"digest"`
 	Offset int    `json:"offset"`
 	Csize  int    `json:"csize"`
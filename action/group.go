@@ -0,0 +1,62 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Group is a group in the image group database, a zero gid is allocated on install
+type Group struct {
+	Name   string `json:"name" hcl:"name,label"`
+	System bool   `json:"system,omitempty" hcl:"system,optional"`
+	Gid    int    `json:"gid,omitempty" hcl:"gid,optional"`
+}
+
+func NewGroup() *Group {
+	return &Group{}
+}
+
+func (g *Group) Key() string {
+	return g.Name
+}
+
+func (g *Group) Type() string {
+	return "Group"
+}
+
+func (g *Group) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(g.Type()),
+		g.Name,
+	}, "|")
+}
+
+func (g *Group) Id() string {
+	return fmt.Sprint(g.Type(), ".", g.Key())
+}
+
+func (g *Group) Condition() *bool {
+	return nil
+}
+
+func (g *Group) MayFail() bool {
+	return false
+}
+
+func (g *Group) IsValid() bool {
+	if validAccountName(g.Name) && g.Gid >= 0 {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,64 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HardLink is an additional name for a File in the same package, Target is the path
+// of that file, content, mode and ownership are shared with it
+type HardLink struct {
+	Path   string `json:"path" hcl:"path,label"`
+	Target string `json:"target" hcl:"target"`
+}
+
+func NewHardLink() *HardLink {
+	return &HardLink{}
+}
+
+func (h *HardLink) Key() string {
+	return h.Path
+}
+
+func (h *HardLink) Type() string {
+	return "HardLink"
+}
+
+func (h *HardLink) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(h.Type()),
+		"",
+		"",
+		h.Path,
+	}, "|")
+}
+
+func (h *HardLink) Id() string {
+	return fmt.Sprint(h.Type(), ".", h.Key())
+}
+
+func (h *HardLink) Condition() *bool {
+	return nil
+}
+
+func (h *HardLink) MayFail() bool {
+	return false
+}
+
+func (h *HardLink) IsValid() bool {
+	if h.Path != "" && h.Target != "" && h.Path != h.Target {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Hook phases, pre-install also runs before an upgrade while post-upgrade replaces
+// post-install, remove hooks do not run when a package is replaced by an upgrade
+const (
+	HookPreInstall  = "pre-install"
+	HookPostInstall = "post-install"
+	HookPreRemove   = "pre-remove"
+	HookPostRemove  = "post-remove"
+	HookPostUpgrade = "post-upgrade"
+)
+
+type Hook struct {
+	Name    string            `json:"name" hcl:"name,label"`
+	Phase   string            `json:"phase" hcl:"phase"`
+	Command string            `json:"command" hcl:"command"`
+	Timeout string            `json:"timeout,omitempty" hcl:"timeout,optional"`
+	Env     map[string]string `json:"env,omitempty" hcl:"env,optional"`
+}
+
+func NewHook() *Hook {
+	return &Hook{}
+}
+
+func (h *Hook) Key() string {
+	return h.Name
+}
+
+func (h *Hook) Type() string {
+	return "Hook"
+}
+
+func (h *Hook) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(h.Type()),
+		h.Name,
+		h.Phase,
+		h.Command,
+	}, "|")
+}
+
+func (h *Hook) Id() string {
+	return fmt.Sprint(h.Type(), ".", h.Key())
+}
+
+func (h *Hook) Condition() *bool {
+	return nil
+}
+
+func (h *Hook) MayFail() bool {
+	return false
+}
+
+func (h *Hook) IsValid() bool {
+	switch h.Phase {
+	case HookPreInstall, HookPostInstall, HookPreRemove, HookPostRemove, HookPostUpgrade:
+	default:
+		return false
+	}
+
+	if h.Timeout != "" {
+		if _, err := time.ParseDuration(h.Timeout); err != nil {
+			return false
+		}
+	}
+
+	if h.Name != "" && h.Command != "" {
+		return true
+	}
+
+	return false
+}
@@ -20,6 +20,9 @@ import (
 	"strings"
 )
 
+// FsSections lists the sections holding file system objects, HardLink must remain last
+var FsSections = []string{"Dir", "File", "SymLink", "Node", "HardLink"}
+
 type Manifest struct {
 	Zpkg *Zpkg `hcl:"Zpkg,block" json:"zpkg"`
 
@@ -27,14 +30,26 @@ type Manifest struct {
 
 	Requirements []*Requirement `hcl:"Requirement,block" json:"requirement,omitempty"`
 
+	Groups []*Group `hcl:"Group,block" json:"group,omitempty"`
+	Users  []*User  `hcl:"User,block" json:"user,omitempty"`
+
 	Dirs     []*Dir     `hcl:"Dir,block" json:"dir,omitempty"`
 	Files    []*File    `hcl:"File,block" json:"file,omitempty"`
 	SymLinks []*SymLink `hcl:"SymLink,block" json:"symlink,omitempty"`
 
+	HardLinks []*HardLink `hcl:"HardLink,block" json:"hardlink,omitempty"`
+	Nodes     []*Node     `hcl:"Node,block" json:"node,omitempty"`
+
 	Templates []*Template `hcl:"Template,block" json:"template,omitempty"`
 
+	Alternatives []*Alternative `hcl:"Alternative,block" json:"alternative,omitempty"`
+	Diversions   []*Divert      `hcl:"Divert,block" json:"divert,omitempty"`
+
 	Services []*Service `hcl:"Service,block" json:"service,omitempty"`
 
+	Hooks    []*Hook    `hcl:"Hook,block" json:"hook,omitempty"`
+	Triggers []*Trigger `hcl:"Trigger,block" json:"trigger,omitempty"`
+
 	Signatures []*Signature `hcl:"Signature,block" json:"signature,omitempty"`
 
 	index map[string]int
@@ -64,6 +79,20 @@ func (m *Manifest) Add(action Action) {
 			m.Requirements = append(m.Requirements, action.(*Requirement))
 			m.index[action.Id()] = len(m.Requirements) - 1
 		}
+	case "Group":
+		if m.Exists(action) {
+			m.Groups[m.index[action.Id()]] = action.(*Group)
+		} else {
+			m.Groups = append(m.Groups, action.(*Group))
+			m.index[action.Id()] = len(m.Groups) - 1
+		}
+	case "User":
+		if m.Exists(action) {
+			m.Users[m.index[action.Id()]] = action.(*User)
+		} else {
+			m.Users = append(m.Users, action.(*User))
+			m.index[action.Id()] = len(m.Users) - 1
+		}
 	case "Dir":
 		if m.Exists(action) {
 			m.Dirs[m.index[action.Id()]] = action.(*Dir)
@@ -85,6 +114,20 @@ func (m *Manifest) Add(action Action) {
 			m.Templates = append(m.Templates, action.(*Template))
 			m.index[action.Id()] = len(m.Templates) - 1
 		}
+	case "Alternative":
+		if m.Exists(action) {
+			m.Alternatives[m.index[action.Id()]] = action.(*Alternative)
+		} else {
+			m.Alternatives = append(m.Alternatives, action.(*Alternative))
+			m.index[action.Id()] = len(m.Alternatives) - 1
+		}
+	case "Divert":
+		if m.Exists(action) {
+			m.Diversions[m.index[action.Id()]] = action.(*Divert)
+		} else {
+			m.Diversions = append(m.Diversions, action.(*Divert))
+			m.index[action.Id()] = len(m.Diversions) - 1
+		}
 	case "Service":
 		if m.Exists(action) {
 			m.Services[m.index[action.Id()]] = action.(*Service)
@@ -92,6 +135,20 @@ func (m *Manifest) Add(action Action) {
 			m.Services = append(m.Services, action.(*Service))
 			m.index[action.Id()] = len(m.Services) - 1
 		}
+	case "Hook":
+		if m.Exists(action) {
+			m.Hooks[m.index[action.Id()]] = action.(*Hook)
+		} else {
+			m.Hooks = append(m.Hooks, action.(*Hook))
+			m.index[action.Id()] = len(m.Hooks) - 1
+		}
+	case "Trigger":
+		if m.Exists(action) {
+			m.Triggers[m.index[action.Id()]] = action.(*Trigger)
+		} else {
+			m.Triggers = append(m.Triggers, action.(*Trigger))
+			m.index[action.Id()] = len(m.Triggers) - 1
+		}
 	case "Signature":
 		if m.Exists(action) {
 			m.Signatures[m.index[action.Id()]] = action.(*Signature)
@@ -106,6 +163,20 @@ func (m *Manifest) Add(action Action) {
 			m.SymLinks = append(m.SymLinks, action.(*SymLink))
 			m.index[action.Id()] = len(m.SymLinks) - 1
 		}
+	case "HardLink":
+		if m.Exists(action) {
+			m.HardLinks[m.index[action.Id()]] = action.(*HardLink)
+		} else {
+			m.HardLinks = append(m.HardLinks, action.(*HardLink))
+			m.index[action.Id()] = len(m.HardLinks) - 1
+		}
+	case "Node":
+		if m.Exists(action) {
+			m.Nodes[m.index[action.Id()]] = action.(*Node)
+		} else {
+			m.Nodes = append(m.Nodes, action.(*Node))
+			m.index[action.Id()] = len(m.Nodes) - 1
+		}
 	}
 }
 
@@ -130,6 +201,14 @@ func (m *Manifest) Section(filters ...string) Actions {
 			for _, item := range m.Requirements {
 				items = append(items, item)
 			}
+		case "Group":
+			for _, item := range m.Groups {
+				items = append(items, item)
+			}
+		case "User":
+			for _, item := range m.Users {
+				items = append(items, item)
+			}
 		case "Dir":
 			for _, item := range m.Dirs {
 				items = append(items, item)
@@ -142,14 +221,38 @@ func (m *Manifest) Section(filters ...string) Actions {
 			for _, item := range m.SymLinks {
 				items = append(items, item)
 			}
+		case "HardLink":
+			for _, item := range m.HardLinks {
+				items = append(items, item)
+			}
+		case "Node":
+			for _, item := range m.Nodes {
+				items = append(items, item)
+			}
 		case "Template":
 			for _, item := range m.Templates {
 				items = append(items, item)
 			}
+		case "Alternative":
+			for _, item := range m.Alternatives {
+				items = append(items, item)
+			}
+		case "Divert":
+			for _, item := range m.Diversions {
+				items = append(items, item)
+			}
 		case "Service":
 			for _, item := range m.Services {
 				items = append(items, item)
 			}
+		case "Hook":
+			for _, item := range m.Hooks {
+				items = append(items, item)
+			}
+		case "Trigger":
+			for _, item := range m.Triggers {
+				items = append(items, item)
+			}
 		case "Signature":
 			for _, item := range m.Signatures {
 				items = append(items, item)
@@ -169,6 +272,14 @@ func (m *Manifest) Index() {
 		m.index[act.Id()] = index
 	}
 
+	for index, act := range m.Groups {
+		m.index[act.Id()] = index
+	}
+
+	for index, act := range m.Users {
+		m.index[act.Id()] = index
+	}
+
 	for index, act := range m.Dirs {
 		m.index[act.Id()] = index
 	}
@@ -181,14 +292,38 @@ func (m *Manifest) Index() {
 		m.index[act.Id()] = index
 	}
 
+	for index, act := range m.HardLinks {
+		m.index[act.Id()] = index
+	}
+
+	for index, act := range m.Nodes {
+		m.index[act.Id()] = index
+	}
+
 	for index, act := range m.Templates {
 		m.index[act.Id()] = index
 	}
 
+	for index, act := range m.Alternatives {
+		m.index[act.Id()] = index
+	}
+
+	for index, act := range m.Diversions {
+		m.index[act.Id()] = index
+	}
+
 	for index, act := range m.Services {
 		m.index[act.Id()] = index
 	}
 
+	for index, act := range m.Hooks {
+		m.index[act.Id()] = index
+	}
+
+	for index, act := range m.Triggers {
+		m.index[act.Id()] = index
+	}
+
 	for index, act := range m.Signatures {
 		m.index[act.Id()] = index
 	}
@@ -197,23 +332,109 @@ func (m *Manifest) Index() {
 func (m *Manifest) Actions() Actions {
 	var actions Actions
 
-	fs := m.Section("Dir", "File", "SymLink")
-	sort.Sort(fs)
+	fs := m.FsObjects()
 
 	actions = append(actions, m.Zpkg)
 	actions = append(actions, m.Section("Tag")...)
 	actions = append(actions, m.Section("Requirement")...)
+	actions = append(actions, m.Section("Group", "User")...)
 	actions = append(actions, m.Section("Template")...)
+	actions = append(actions, m.Section("Alternative")...)
+	actions = append(actions, m.Section("Divert")...)
 	actions = append(actions, m.Section("Service")...)
+	actions = append(actions, m.Section("Hook")...)
+	actions = append(actions, m.Section("Trigger")...)
 	actions = append(actions, m.Section("Signature")...)
 	actions = append(actions, fs...)
 
 	return actions
 }
 
+// FsObjects returns file system object actions in install order, by path with hard links
+// last so that the files they name already exist
+func (m *Manifest) FsObjects() Actions {
+	objects := m.Section(FsSections[:len(FsSections)-1]...)
+	sort.Sort(objects)
+
+	links := m.Section("HardLink")
+	sort.Sort(links)
+
+	return append(objects, links...)
+}
+
+// ValidatePaths ensures that file system objects and the paths they reference stay
+// inside the image root
+func (m *Manifest) ValidatePaths() error {
+	for _, act := range m.Section(FsSections...) {
+		if !SafePath(act.Key()) {
+			return fmt.Errorf("Action %s: %s path escapes the image root", act.Type(), act.Key())
+		}
+	}
+
+	for _, link := range m.SymLinks {
+		if !SafeLinkTarget(link.Path, link.Target) {
+			return fmt.Errorf("Action SymLink: %s target %s escapes the image root", link.Key(), link.Target)
+		}
+	}
+
+	for _, link := range m.HardLinks {
+		if !SafePath(link.Target) {
+			return fmt.Errorf("Action HardLink: %s target %s escapes the image root", link.Key(), link.Target)
+		}
+	}
+
+	for _, alt := range m.Alternatives {
+		if !SafePath(alt.Link) || !SafePath(alt.Target) {
+			return fmt.Errorf("Action Alternative: %s link or target escapes the image root", alt.Key())
+		}
+	}
+
+	for _, divert := range m.Diversions {
+		if !SafePath(divert.Path) || !SafePath(divert.To) {
+			return fmt.Errorf("Action Divert: %s path or destination escapes the image root", divert.Key())
+		}
+	}
+
+	// Template paths may be written as absolute paths within the image
+	for _, tpl := range m.Templates {
+		if !SafePath(strings.TrimPrefix(tpl.Source, "/")) || (tpl.Output != "" && !SafePath(strings.TrimPrefix(tpl.Output, "/"))) {
+			return fmt.Errorf("Action Template: %s source or output escapes the image root", tpl.Key())
+		}
+	}
+
+	return nil
+}
+
+// Sort orders every section by key so that manifest content does not depend on
+// Zpkgfile declaration or filesystem walk order, used for reproducible builds
+func (m *Manifest) Sort() {
+	sort.SliceStable(m.Tags, func(i, j int) bool { return m.Tags[i].Key() < m.Tags[j].Key() })
+	sort.SliceStable(m.Requirements, func(i, j int) bool { return m.Requirements[i].Key() < m.Requirements[j].Key() })
+	sort.SliceStable(m.Groups, func(i, j int) bool { return m.Groups[i].Key() < m.Groups[j].Key() })
+	sort.SliceStable(m.Users, func(i, j int) bool { return m.Users[i].Key() < m.Users[j].Key() })
+	sort.SliceStable(m.Dirs, func(i, j int) bool { return m.Dirs[i].Key() < m.Dirs[j].Key() })
+	sort.SliceStable(m.Files, func(i, j int) bool { return m.Files[i].Key() < m.Files[j].Key() })
+	sort.SliceStable(m.SymLinks, func(i, j int) bool { return m.SymLinks[i].Key() < m.SymLinks[j].Key() })
+	sort.SliceStable(m.HardLinks, func(i, j int) bool { return m.HardLinks[i].Key() < m.HardLinks[j].Key() })
+	sort.SliceStable(m.Nodes, func(i, j int) bool { return m.Nodes[i].Key() < m.Nodes[j].Key() })
+	sort.SliceStable(m.Templates, func(i, j int) bool { return m.Templates[i].Key() < m.Templates[j].Key() })
+	sort.SliceStable(m.Alternatives, func(i, j int) bool { return m.Alternatives[i].Key() < m.Alternatives[j].Key() })
+	sort.SliceStable(m.Diversions, func(i, j int) bool { return m.Diversions[i].Key() < m.Diversions[j].Key() })
+	sort.SliceStable(m.Services, func(i, j int) bool { return m.Services[i].Key() < m.Services[j].Key() })
+	sort.SliceStable(m.Hooks, func(i, j int) bool { return m.Hooks[i].Key() < m.Hooks[j].Key() })
+	sort.SliceStable(m.Triggers, func(i, j int) bool { return m.Triggers[i].Key() < m.Triggers[j].Key() })
+
+	m.index = make(map[string]int)
+	m.Index()
+}
+
 func (m *Manifest) Validate() error {
 	var actions Actions
 
+	if m.Zpkg == nil {
+		return errors.New("Action Zpkg: manifest does not describe a package")
+	}
+
 	// Do not allow a requirement to name itself
 	reqs := m.Section("Requirement")
 	for _, req := range reqs {
@@ -222,8 +443,13 @@ func (m *Manifest) Validate() error {
 		}
 	}
 
+	err := m.ValidatePaths()
+	if err != nil {
+		return err
+	}
+
 	// Ensure there are no duplicate paths present for FS actions
-	actions = m.Section("Dir", "File", "SymLink")
+	actions = m.Section(FsSections...)
 
 	sort.Sort(actions)
 	for index, action := range actions {
@@ -248,6 +474,67 @@ func (m *Manifest) Validate() error {
 		}
 	}
 
+	// Ensure that hard links name a packaged file
+	for _, link := range m.Section("HardLink") {
+		if _, ok := m.index["File."+link.(*HardLink).Target]; !ok || !link.IsValid() {
+			return fmt.Errorf("Action HardLink: %s does not link to a packaged file", link.Key())
+		}
+	}
+
+	// Ensure special files are of a known kind
+	for _, node := range m.Section("Node") {
+		if kind := node.(*Node).Kind; kind != NodeFifo && kind != NodeChar && kind != NodeBlock {
+			return fmt.Errorf("Action Node: %s has unknown kind %s", node.Key(), kind)
+		}
+	}
+
+	// Ensure alternatives link to a distinct path the package does not ship itself
+	for _, alt := range m.Section("Alternative") {
+		if !alt.IsValid() {
+			return fmt.Errorf("Action Alternative: %s requires a target other than the link", alt.Key())
+		}
+
+		for _, fsType := range FsSections {
+			if _, ok := m.index[fsType+"."+alt.Key()]; ok {
+				return fmt.Errorf("Action Alternative: %s conflicts with a packaged %s", alt.Key(), fsType)
+			}
+		}
+	}
+
+	// Ensure diversions move a file elsewhere, the package may not ship the destination itself
+	for _, divert := range m.Section("Divert") {
+		if !divert.IsValid() {
+			return fmt.Errorf("Action Divert: %s requires a destination other than the path", divert.Key())
+		}
+
+		for _, fsType := range FsSections {
+			if _, ok := m.index[fsType+"."+divert.(*Divert).To]; ok {
+				return fmt.Errorf("Action Divert: %s destination conflicts with a packaged %s", divert.Key(), fsType)
+			}
+		}
+	}
+
+	// Ensure accounts can be written to the passwd and group databases
+	for _, account := range m.Section("Group", "User") {
+		if !account.IsValid() {
+			return fmt.Errorf("Action %s: %s is not a valid account", account.Type(), account.Key())
+		}
+	}
+
+	// Ensure hooks name a known phase and a command
+	for _, hook := range m.Section("Hook") {
+		if !hook.IsValid() {
+			return fmt.Errorf("Action Hook: %s requires a command, a valid phase and timeout", hook.Key())
+		}
+	}
+
+	// Ensure triggers watch paths inside the image and name a command
+	for _, trigger := range m.Section("Trigger") {
+		if !trigger.IsValid() {
+			return fmt.Errorf("Action Trigger: %s requires a command, paths inside the image and a valid timeout", trigger.Key())
+		}
+	}
+
 	// TODO add a check to ensure that service includes the systemd unit
 
 	return nil
@@ -0,0 +1,82 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Node kinds
+const (
+	NodeFifo  = "fifo"
+	NodeChar  = "char"
+	NodeBlock = "block"
+)
+
+// Node is a special file, a fifo or a character or block device
+type Node struct {
+	Path  string `json:"path" hcl:"path,label"`
+	Kind  string `json:"kind" hcl:"kind"`
+	Major int    `json:"major,omitempty" hcl:"major,optional"`
+	Minor int    `json:"minor,omitempty" hcl:"minor,optional"`
+
+	Owner string `json:"owner" hcl:"owner,optional"`
+	Group string `json:"group" hcl:"group,optional"`
+	Mode  string `json:"mode" hcl:"mode,optional"`
+}
+
+func NewNode() *Node {
+	return &Node{}
+}
+
+func (n *Node) Key() string {
+	return n.Path
+}
+
+func (n *Node) Type() string {
+	return "Node"
+}
+
+func (n *Node) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(n.Type()),
+		n.Mode,
+		n.Owner + ":" + n.Group,
+		n.Path,
+	}, "|")
+}
+
+func (n *Node) Id() string {
+	return fmt.Sprint(n.Type(), ".", n.Key())
+}
+
+func (n *Node) Condition() *bool {
+	return nil
+}
+
+func (n *Node) MayFail() bool {
+	return false
+}
+
+func (n *Node) IsValid() bool {
+	switch n.Kind {
+	case NodeFifo, NodeChar, NodeBlock:
+	default:
+		return false
+	}
+
+	if n.Path != "" && n.Owner != "" && n.Group != "" && n.Mode != "" && n.Major >= 0 && n.Minor >= 0 {
+		return true
+	}
+
+	return false
+}
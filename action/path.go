@@ -0,0 +1,48 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"path"
+	"strings"
+)
+
+// SafePath reports whether an action path stays inside the image root, paths must be
+// relative and may not contain parent references
+func SafePath(objectPath string) bool {
+	if objectPath == "" || path.IsAbs(objectPath) || path.Clean(objectPath) == "." {
+		return false
+	}
+
+	for _, part := range strings.Split(objectPath, "/") {
+		if part == ".." {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SafeLinkTarget reports whether a symlink target resolves inside the image root, absolute
+// targets are relative to the image root once installed
+func SafeLinkTarget(linkPath string, target string) bool {
+	if target == "" {
+		return false
+	}
+
+	if path.IsAbs(target) {
+		return true
+	}
+
+	resolved := path.Clean(path.Join(path.Dir(linkPath), target))
+
+	return resolved != ".." && !strings.HasPrefix(resolved, "../")
+}
@@ -18,7 +18,7 @@ import (
 type Requirement struct {
 	Name      string `json:"name" hcl:"name,label"`
 	Method    string `json:"method" hcl:"method"`
-	Operation string `json:"operation,omitempty" hcl:"operation"`
+	Operation string `json:"operation,omitempty" hcl:"operation,optional"`
 	Version   string `json:"version,omitempty" hcl:"version,optional"`
 }
 
@@ -16,14 +16,40 @@ import (
 )
 
 type Service struct {
-	Name   string `json:"name" hcl:"name,label"`
-	Timer bool  `json:"timer" hcl:"timer,optional"`
+	Name  string `json:"name" hcl:"name,label"`
+	Timer bool   `json:"timer" hcl:"timer,optional"`
+
+	// Lifecycle policy, services are enabled and started unless enable is false and
+	// stopped on remove unless stop_on_remove is false
+	Enable           *bool `json:"enable,omitempty" hcl:"enable,optional"`
+	RestartOnUpgrade bool  `json:"restart_on_upgrade,omitempty" hcl:"restart_on_upgrade,optional"`
+	ReloadOnChange   bool  `json:"reload_on_change,omitempty" hcl:"reload_on_change,optional"`
+	StopOnRemove     *bool `json:"stop_on_remove,omitempty" hcl:"stop_on_remove,optional"`
 }
 
 func NewService() *Service {
 	return &Service{}
 }
 
+// Enabled reports whether the service should be enabled and started
+func (s *Service) Enabled() bool {
+	return s.Enable == nil || *s.Enable
+}
+
+// Stopped reports whether the service should be stopped when its package is removed
+func (s *Service) Stopped() bool {
+	return s.StopOnRemove == nil || *s.StopOnRemove
+}
+
+// Unit is the unit systemd activates, the timer for timer driven services
+func (s *Service) Unit() string {
+	if s.Timer {
+		return s.Name + ".timer"
+	}
+
+	return s.Name + ".service"
+}
+
 func (s *Service) Key() string {
 	return s.Name
 }
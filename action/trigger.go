@@ -0,0 +1,98 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Trigger runs a command once per transaction when any package installs or removes
+// a path below one of the watched paths, triggers are identified by name across packages
+type Trigger struct {
+	Name    string            `json:"name" hcl:"name,label"`
+	Paths   []string          `json:"paths" hcl:"paths"`
+	Command string            `json:"command" hcl:"command"`
+	Timeout string            `json:"timeout,omitempty" hcl:"timeout,optional"`
+	Env     map[string]string `json:"env,omitempty" hcl:"env,optional"`
+}
+
+func NewTrigger() *Trigger {
+	return &Trigger{}
+}
+
+func (t *Trigger) Key() string {
+	return t.Name
+}
+
+func (t *Trigger) Type() string {
+	return "Trigger"
+}
+
+func (t *Trigger) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(t.Type()),
+		t.Name,
+		strings.Join(t.Paths, ","),
+		t.Command,
+	}, "|")
+}
+
+func (t *Trigger) Id() string {
+	return fmt.Sprint(t.Type(), ".", t.Key())
+}
+
+func (t *Trigger) Condition() *bool {
+	return nil
+}
+
+func (t *Trigger) MayFail() bool {
+	return false
+}
+
+// Watches reports whether a path is one of the watched paths or lies below one
+func (t *Trigger) Watches(objectPath string) bool {
+	for _, watched := range t.Paths {
+		watched = path.Clean(watched)
+
+		if objectPath == watched || strings.HasPrefix(objectPath, watched+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *Trigger) IsValid() bool {
+	if t.Timeout != "" {
+		if _, err := time.ParseDuration(t.Timeout); err != nil {
+			return false
+		}
+	}
+
+	if len(t.Paths) == 0 {
+		return false
+	}
+
+	for _, watched := range t.Paths {
+		if !SafePath(watched) {
+			return false
+		}
+	}
+
+	if t.Name != "" && t.Command != "" {
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package action
+
+import (
+	"fmt"
+	"strings"
+)
+
+// User is an account in the image passwd database, a zero uid is allocated on install
+// and the primary group defaults to a group of the same name
+type User struct {
+	Name   string `json:"name" hcl:"name,label"`
+	System bool   `json:"system,omitempty" hcl:"system,optional"`
+	Uid    int    `json:"uid,omitempty" hcl:"uid,optional"`
+	Group  string `json:"group,omitempty" hcl:"group,optional"`
+	Home   string `json:"home,omitempty" hcl:"home,optional"`
+	Shell  string `json:"shell,omitempty" hcl:"shell,optional"`
+}
+
+func NewUser() *User {
+	return &User{}
+}
+
+func (u *User) Key() string {
+	return u.Name
+}
+
+func (u *User) Type() string {
+	return "User"
+}
+
+func (u *User) Columns() string {
+	return strings.Join([]string{
+		strings.ToUpper(u.Type()),
+		u.Name,
+		u.PrimaryGroup(),
+		u.Home,
+		u.Shell,
+	}, "|")
+}
+
+func (u *User) Id() string {
+	return fmt.Sprint(u.Type(), ".", u.Key())
+}
+
+func (u *User) Condition() *bool {
+	return nil
+}
+
+func (u *User) MayFail() bool {
+	return false
+}
+
+func (u *User) IsValid() bool {
+	if validAccountName(u.Name) && u.Uid >= 0 && !strings.ContainsAny(u.Group+u.Home+u.Shell, ":\n") {
+		return true
+	}
+
+	return false
+}
+
+func (u *User) PrimaryGroup() string {
+	if u.Group == "" {
+		return u.Name
+	}
+
+	return u.Group
+}
+
+func validAccountName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, ": \t\n/") && !strings.HasPrefix(name, "-")
+}
@@ -0,0 +1,50 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+)
+
+type ZpsAlternativesCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsAlternativesCommand() *ZpsAlternativesCommand {
+	cmd := &ZpsAlternativesCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "alternatives"
+	cmd.Short = "Manage links shared by several packages"
+	cmd.Long = "Manage links shared by several packages"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	cmd.AddCommand(NewZpsAlternativesAutoCommand().Command)
+	cmd.AddCommand(NewZpsAlternativesListCommand().Command)
+	cmd.AddCommand(NewZpsAlternativesSetCommand().Command)
+	return cmd
+}
+
+func (z *ZpsAlternativesCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsAlternativesCommand) run(cmd *cobra.Command, args []string) error {
+	cmd.Help()
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+	"github.com/zps-io/zps/zpm"
+)
+
+type ZpsAlternativesListCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsAlternativesListCommand() *ZpsAlternativesListCommand {
+	cmd := &ZpsAlternativesListCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "list"
+	cmd.Short = "List registered alternatives and the packages owning their links"
+	cmd.Long = "List registered alternatives and the packages owning their links"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	return cmd
+}
+
+func (z *ZpsAlternativesListCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsAlternativesListCommand) run(cmd *cobra.Command, args []string) error {
+	image, _ := cmd.Flags().GetString("image")
+
+	// Load manager
+	mgr, err := zpm.NewManager(image)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	SetupEventHandlers(mgr.Emitter, z.Ui)
+
+	alternatives, err := mgr.AlternativesList()
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	if alternatives != nil {
+		z.Info(columnize.SimpleFormat(alternatives))
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+	"github.com/zps-io/zps/zpm"
+)
+
+type ZpsAlternativesSetCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsAlternativesSetCommand() *ZpsAlternativesSetCommand {
+	cmd := &ZpsAlternativesSetCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "set [LINK] [PKG]"
+	cmd.Short = "Select the package owning an alternative link"
+	cmd.Long = "Select the package owning an alternative link"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	return cmd
+}
+
+func (z *ZpsAlternativesSetCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsAlternativesSetCommand) run(cmd *cobra.Command, args []string) error {
+	image, _ := cmd.Flags().GetString("image")
+
+	if cmd.Flags().NArg() != 2 {
+		return errors.New("Must provide a link and a package name")
+	}
+
+	// Load manager
+	mgr, err := zpm.NewManager(image)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	SetupEventHandlers(mgr.Emitter, z.Ui)
+
+	err = mgr.AlternativesSet(cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	return nil
+}
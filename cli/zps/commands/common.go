@@ -11,6 +11,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -19,6 +20,7 @@ import (
 	"github.com/chuckpreslar/emission"
 
 	"github.com/zps-io/zps/cli"
+	"github.com/zps-io/zps/zps"
 )
 
 func SetupEventHandlers(emitter *emission.Emitter, ui *cli.Ui) {
@@ -129,4 +131,25 @@ func SetupEventHandlers(emitter *emission.Emitter, ui *cli.Ui) {
 	emitter.On("transaction.remove", func(message string) {
 		ui.Red(fmt.Sprint("- ", message))
 	})
+
+	emitter.On("transaction.rollback", func(message string) {
+		ui.Warn(fmt.Sprint("~ ", message))
+	})
+}
+
+// FatalSolverError explains unsatisfiable requests line by line before exiting
+func FatalSolverError(ui *cli.Ui, err error) {
+	var unsat *zps.UnsatisfiableError
+
+	if errors.As(err, &unsat) {
+		ui.Error("x no solution for requested jobs")
+
+		for _, line := range unsat.Explain() {
+			ui.Warn(fmt.Sprint("~ ", line))
+		}
+
+		os.Exit(1)
+	}
+
+	ui.Fatal(err.Error())
 }
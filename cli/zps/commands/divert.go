@@ -0,0 +1,50 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+)
+
+type ZpsDivertCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsDivertCommand() *ZpsDivertCommand {
+	cmd := &ZpsDivertCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "divert"
+	cmd.Short = "Manage file diversions"
+	cmd.Long = "Manage file diversions"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	cmd.AddCommand(NewZpsDivertAddCommand().Command)
+	cmd.AddCommand(NewZpsDivertListCommand().Command)
+	cmd.AddCommand(NewZpsDivertRemoveCommand().Command)
+	return cmd
+}
+
+func (z *ZpsDivertCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsDivertCommand) run(cmd *cobra.Command, args []string) error {
+	cmd.Help()
+	return nil
+}
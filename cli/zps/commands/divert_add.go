@@ -0,0 +1,75 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+	"github.com/zps-io/zps/zpm"
+)
+
+type ZpsDivertAddCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsDivertAddCommand() *ZpsDivertAddCommand {
+	cmd := &ZpsDivertAddCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "add [PATH]"
+	cmd.Short = "Divert the file packages install at a path to another location"
+	cmd.Long = "Divert the file packages install at a path to another location"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	cmd.Flags().String("to", "", "location packages install the file to instead")
+
+	return cmd
+}
+
+func (z *ZpsDivertAddCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsDivertAddCommand) run(cmd *cobra.Command, args []string) error {
+	image, _ := cmd.Flags().GetString("image")
+	to, _ := cmd.Flags().GetString("to")
+
+	if cmd.Flags().NArg() != 1 {
+		return errors.New("Must provide a path to divert")
+	}
+
+	if to == "" {
+		return errors.New("Must provide a location to divert to with --to")
+	}
+
+	// Load manager
+	mgr, err := zpm.NewManager(image)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	SetupEventHandlers(mgr.Emitter, z.Ui)
+
+	err = mgr.DivertAdd(cmd.Flags().Arg(0), to)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	return nil
+}
@@ -34,6 +34,8 @@ func NewZpsFetchCommand() *ZpsFetchCommand {
 	cmd.PreRunE = cmd.setup
 	cmd.RunE = cmd.run
 
+	cmd.Flags().String("policy", "", "Solver policy [updated|minimal-change|newest|lowest-satisfying|repo-pinned]")
+
 	return cmd
 }
 
@@ -47,6 +49,7 @@ func (z *ZpsFetchCommand) setup(cmd *cobra.Command, args []string) error {
 
 func (z *ZpsFetchCommand) run(cmd *cobra.Command, args []string) error {
 	image, _ := cmd.Flags().GetString("image")
+	policy, _ := cmd.Flags().GetString("policy")
 
 	if cmd.Flags().NArg() == 0 {
 		return errors.New("Must provide at least one package uri to install")
@@ -60,6 +63,11 @@ func (z *ZpsFetchCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
+	err = mgr.SetPolicy(policy)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
 	err = mgr.Fetch(cmd.Flags().Args())
 	if err != nil {
 		z.Fatal(err.Error())
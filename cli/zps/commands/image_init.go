@@ -39,6 +39,7 @@ func NewZpsImageInitCommand() *ZpsImageInitCommand {
 	cmd.Flags().Bool("configure", false, "configure the image after init")
 	cmd.Flags().Bool("force", false, "purge the image path, before init")
 	cmd.Flags().Bool("helper", false, "reinstall ZPS helper")
+	cmd.Flags().String("locked", "", "install exactly the packages pinned in a lockfile")
 
 	return cmd
 }
@@ -61,6 +62,7 @@ func (z *ZpsImageInitCommand) run(cmd *cobra.Command, args []string) error {
 	configure, _ := cmd.Flags().GetBool("configure")
 	force, _ := cmd.Flags().GetBool("force")
 	helper, _ := cmd.Flags().GetBool("helper")
+	locked, _ := cmd.Flags().GetString("locked")
 
 	// Load manager
 	mgr, err := zpm.NewManager(image)
@@ -70,6 +72,13 @@ func (z *ZpsImageInitCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
+	if locked != "" {
+		err = mgr.SetLocked(locked)
+		if err != nil {
+			z.Fatal(err.Error())
+		}
+	}
+
 	err = mgr.ImageInit(cmd.Flags().Arg(0), name, os, arch, path, profile, configure, force, helper)
 	if err != nil {
 		z.Fatal(err.Error())
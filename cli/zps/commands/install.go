@@ -34,6 +34,9 @@ func NewZpsInstallCommand() *ZpsInstallCommand {
 	cmd.PreRunE = cmd.setup
 	cmd.RunE = cmd.run
 
+	cmd.Flags().String("locked", "", "Install exactly the packages pinned in a lockfile, when no packages are named unlocked packages are removed")
+	cmd.Flags().String("policy", "", "Solver policy [updated|minimal-change|newest|lowest-satisfying|repo-pinned]")
+
 	return cmd
 }
 
@@ -47,8 +50,10 @@ func (z *ZpsInstallCommand) setup(cmd *cobra.Command, args []string) error {
 
 func (z *ZpsInstallCommand) run(cmd *cobra.Command, args []string) error {
 	image, _ := cmd.Flags().GetString("image")
+	policy, _ := cmd.Flags().GetString("policy")
+	locked, _ := cmd.Flags().GetString("locked")
 
-	if cmd.Flags().NArg() == 0 {
+	if cmd.Flags().NArg() == 0 && locked == "" {
 		return errors.New("Must provide at least one package uri to install")
 	}
 
@@ -60,10 +65,22 @@ func (z *ZpsInstallCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
-	err = mgr.Install(cmd.Flags().Args(), nil)
+	err = mgr.SetPolicy(policy)
 	if err != nil {
 		z.Fatal(err.Error())
 	}
 
+	if locked != "" {
+		err = mgr.SetLocked(locked)
+		if err != nil {
+			z.Fatal(err.Error())
+		}
+	}
+
+	err = mgr.Install(cmd.Flags().Args(), nil)
+	if err != nil {
+		FatalSolverError(z.Ui, err)
+	}
+
 	return nil
 }
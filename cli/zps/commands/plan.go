@@ -33,6 +33,8 @@ func NewZpsPlanCommand() *ZpsPlanCommand {
 	cmd.PreRunE = cmd.setup
 	cmd.RunE = cmd.run
 
+	cmd.Flags().String("policy", "", "Solver policy [updated|minimal-change|newest|lowest-satisfying|repo-pinned]")
+
 	return cmd
 }
 
@@ -46,6 +48,7 @@ func (z *ZpsPlanCommand) setup(cmd *cobra.Command, args []string) error {
 
 func (z *ZpsPlanCommand) run(cmd *cobra.Command, args []string) error {
 	image, _ := cmd.Flags().GetString("image")
+	policy, _ := cmd.Flags().GetString("policy")
 
 	// Load manager
 	mgr, err := zpm.NewManager(image)
@@ -55,6 +58,11 @@ func (z *ZpsPlanCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
+	err = mgr.SetPolicy(policy)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
 	if cmd.Flags().Arg(0) == "" {
 		return errors.New("plan action required")
 	}
@@ -65,7 +73,7 @@ func (z *ZpsPlanCommand) run(cmd *cobra.Command, args []string) error {
 
 	_, err = mgr.Plan(cmd.Flags().Arg(0), cmd.Flags().Args()[1:])
 	if err != nil {
-		z.Fatal(err.Error())
+		FatalSolverError(z.Ui, err)
 	}
 
 	return nil
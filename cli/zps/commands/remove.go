@@ -33,6 +33,8 @@ func NewZpsRemoveCommand() *ZpsRemoveCommand {
 	cmd.PreRunE = cmd.setup
 	cmd.RunE = cmd.run
 
+	cmd.Flags().String("policy", "", "Solver policy [updated|minimal-change|newest|lowest-satisfying|repo-pinned]")
+
 	return cmd
 }
 
@@ -46,6 +48,7 @@ func (z *ZpsRemoveCommand) setup(cmd *cobra.Command, args []string) error {
 
 func (z *ZpsRemoveCommand) run(cmd *cobra.Command, args []string) error {
 	image, _ := cmd.Flags().GetString("image")
+	policy, _ := cmd.Flags().GetString("policy")
 
 	if cmd.Flags().NArg() == 0 {
 		return errors.New("Must provide at least one package uri to remove")
@@ -59,6 +62,11 @@ func (z *ZpsRemoveCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
+	err = mgr.SetPolicy(policy)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
 	err = mgr.Remove(args)
 	if err != nil {
 		z.Fatal(err.Error())
@@ -57,10 +57,12 @@ var ManageCommands = map[string]bool{
 	"cache":       true,
 	"contents":    true,
 	"configure":   true,
+	"fix":         true,
 	"freeze":      true,
 	"info":        true,
 	"install":     true,
 	"list":        true,
+	"lock":        true,
 	"pki":         true,
 	"plan":        true,
 	"refresh":     true,
@@ -70,6 +72,7 @@ var ManageCommands = map[string]bool{
 	"thaw":        true,
 	"transaction": true,
 	"update":      true,
+	"verify":      true,
 }
 
 var PublishCommands = map[string]bool{
@@ -107,16 +110,20 @@ func NewZpsRootCommand() *ZpsRootCommand {
 	cmd.PersistentFlags().Bool("no-color", false, "Disable color")
 	cmd.PersistentFlags().String("image", "", "ZPS image name/id")
 
+	cmd.AddCommand(NewZpsAlternativesCommand().Command)
 	cmd.AddCommand(NewZpsCacheCommand().Command)
 	cmd.AddCommand(NewZpsChannelCommand().Command)
 	cmd.AddCommand(NewZpsContentsCommand().Command)
 	cmd.AddCommand(NewZpsConfigureCommand().Command)
+	cmd.AddCommand(NewZpsDivertCommand().Command)
 	cmd.AddCommand(NewZpsFetchCommand().Command)
+	cmd.AddCommand(NewZpsFixCommand().Command)
 	cmd.AddCommand(NewZpsFreezeCommand().Command)
 	cmd.AddCommand(NewZpsImageCommand().Command)
 	cmd.AddCommand(NewZpsInfoCommand().Command)
 	cmd.AddCommand(NewZpsInstallCommand().Command)
 	cmd.AddCommand(NewZpsListCommand().Command)
+	cmd.AddCommand(NewZpsLockCommand().Command)
 	cmd.AddCommand(NewZpsPkiCommand().Command)
 	cmd.AddCommand(NewZpsPlanCommand().Command)
 	cmd.AddCommand(NewZpsPublishCommand().Command)
@@ -128,6 +135,7 @@ func NewZpsRootCommand() *ZpsRootCommand {
 	cmd.AddCommand(NewZpsTplCommand().Command)
 	cmd.AddCommand(NewZpsTransactionCommand().Command)
 	cmd.AddCommand(NewZpsUpdateCommand().Command)
+	cmd.AddCommand(NewZpsVerifyCommand().Command)
 	cmd.AddCommand(NewZpsVersionCommand().Command)
 	cmd.AddCommand(NewZpsZpkgCommand().Command)
 
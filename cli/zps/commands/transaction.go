@@ -31,6 +31,7 @@ func NewZpsTransactionCommand() *ZpsTransactionCommand {
 	cmd.RunE = cmd.run
 
 	cmd.AddCommand(NewZpsTransactionListCommand().Command)
+	cmd.AddCommand(NewZpsTransactionRollbackCommand().Command)
 	return cmd
 }
 
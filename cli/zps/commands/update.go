@@ -32,6 +32,8 @@ func NewZpsUpdateCommand() *ZpsUpdateCommand {
 	cmd.PreRunE = cmd.setup
 	cmd.RunE = cmd.run
 
+	cmd.Flags().String("policy", "", "Solver policy [updated|minimal-change|newest|lowest-satisfying|repo-pinned]")
+
 	return cmd
 }
 
@@ -45,6 +47,7 @@ func (z *ZpsUpdateCommand) setup(cmd *cobra.Command, args []string) error {
 
 func (z *ZpsUpdateCommand) run(cmd *cobra.Command, args []string) error {
 	image, _ := cmd.Flags().GetString("image")
+	policy, _ := cmd.Flags().GetString("policy")
 
 	// Load manager
 	mgr, err := zpm.NewManager(image)
@@ -54,10 +57,15 @@ func (z *ZpsUpdateCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
-	err = mgr.Update(cmd.Flags().Args())
+	err = mgr.SetPolicy(policy)
 	if err != nil {
 		z.Fatal(err.Error())
 	}
 
+	err = mgr.Update(cmd.Flags().Args())
+	if err != nil {
+		FatalSolverError(z.Ui, err)
+	}
+
 	return nil
 }
@@ -0,0 +1,70 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ryanuber/columnize"
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+	"github.com/zps-io/zps/zpm"
+)
+
+type ZpsVerifyCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsVerifyCommand() *ZpsVerifyCommand {
+	cmd := &ZpsVerifyCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "verify [PKG ...]"
+	cmd.Short = "Verify installed packages against their manifests"
+	cmd.Long = "Verify installed packages against their manifests"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	return cmd
+}
+
+func (z *ZpsVerifyCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsVerifyCommand) run(cmd *cobra.Command, args []string) error {
+	image, _ := cmd.Flags().GetString("image")
+
+	// Load manager
+	mgr, err := zpm.NewManager(image)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	SetupEventHandlers(mgr.Emitter, z.Ui)
+
+	drift, err := mgr.Verify(cmd.Flags().Args())
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	if drift != nil {
+		z.Out(columnize.SimpleFormat(z.Colorize(drift)) + "\n")
+		z.Fatal(fmt.Sprintf("%d objects drifted, run zps fix to repair", len(drift)))
+	}
+
+	return nil
+}
@@ -33,6 +33,7 @@ func NewZpsZpkgCommand() *ZpsZpkgCommand {
 	cmd.AddCommand(NewZpsZpkgBuildCommand().Command)
 	cmd.AddCommand(NewZpsZpkgContentsCommand().Command)
 	cmd.AddCommand(NewZpsZpkgExtractCommand().Command)
+	cmd.AddCommand(NewZpsZpkgImportCommand().Command)
 	cmd.AddCommand(NewZpsZpkgInfoCommand().Command)
 	cmd.AddCommand(NewZpsZpkgManifestCommand().Command)
 	cmd.AddCommand(NewZpsZpkgSignCommand().Command)
@@ -36,6 +36,12 @@ func NewZpsZpkgBuildCommand() *ZpsZpkgBuildCommand {
 	cmd.Flags().String("output-path", "", "Output path for ZPKG")
 	cmd.Flags().Bool("restrict", false, "Restrict included filesystem objects to those present in Zpkgfile")
 	cmd.Flags().Bool("secure", false, "Ensure filesystem objects are super user owned")
+	cmd.Flags().String("compression", "bzip2", "Payload compression: bzip2 or zstd")
+	cmd.Flags().Int("level", 0, "Compression level, 0 selects the default for the compression")
+	cmd.Flags().Bool("reproducible", false, "Build a byte identical ZPKG from the same inputs, requires a source date")
+	cmd.Flags().Bool("elf-requirements", false, "Generate shared library provides and depends from ELF objects")
+	cmd.Flags().Bool("acls", false, "Capture POSIX ACLs of files and directories that do not declare one")
+	cmd.Flags().String("source-date-epoch", "", "Timestamp for reproducible builds in seconds since the epoch, defaults to SOURCE_DATE_EPOCH with --reproducible")
 
 	return cmd
 }
@@ -55,6 +61,12 @@ func (z *ZpsZpkgBuildCommand) run(cmd *cobra.Command, args []string) error {
 	workPath, _ := cmd.Flags().GetString("work-path")
 	restrict, _ := cmd.Flags().GetBool("restrict")
 	secure, _ := cmd.Flags().GetBool("secure")
+	compression, _ := cmd.Flags().GetString("compression")
+	level, _ := cmd.Flags().GetInt("level")
+	reproducible, _ := cmd.Flags().GetBool("reproducible")
+	sourceDateEpoch, _ := cmd.Flags().GetString("source-date-epoch")
+	elfRequirements, _ := cmd.Flags().GetBool("elf-requirements")
+	acls, _ := cmd.Flags().GetBool("acls")
 
 	// Load manager
 	mgr, err := zpm.NewManager(image)
@@ -64,7 +76,7 @@ func (z *ZpsZpkgBuildCommand) run(cmd *cobra.Command, args []string) error {
 
 	SetupEventHandlers(mgr.Emitter, z.Ui)
 
-	err = mgr.ZpkgBuild(cmd.Flags().Arg(0), targetPath, workPath, outputPath, restrict, secure)
+	err = mgr.ZpkgBuild(cmd.Flags().Arg(0), targetPath, workPath, outputPath, restrict, secure, compression, level, reproducible, sourceDateEpoch, elfRequirements, acls)
 	if err != nil {
 		z.Fatal(err.Error())
 	}
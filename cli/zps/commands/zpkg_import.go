@@ -0,0 +1,87 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package commands
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/zps-io/zps/cli"
+	"github.com/zps-io/zps/zpm"
+)
+
+type ZpsZpkgImportCommand struct {
+	*cobra.Command
+	*cli.Ui
+}
+
+func NewZpsZpkgImportCommand() *ZpsZpkgImportCommand {
+	cmd := &ZpsZpkgImportCommand{}
+	cmd.Command = &cobra.Command{}
+	cmd.Ui = cli.NewUi()
+	cmd.Use = "import [ARCHIVE_PATH]"
+	cmd.Short = "Build a ZPKG from a deb, rpm or tarball"
+	cmd.Long = "Build a ZPKG from a deb, rpm or tarball, maintainer scripts are not imported"
+	cmd.PreRunE = cmd.setup
+	cmd.RunE = cmd.run
+
+	cmd.Flags().String("publisher", "", "Publisher of the resulting ZPKG")
+	cmd.Flags().String("name", "", "Override the package name")
+	cmd.Flags().String("version", "", "Override the package version")
+	cmd.Flags().String("work-path", "", "Work path for ZPKG creation")
+	cmd.Flags().String("output-path", "", "Output path for ZPKG")
+	cmd.Flags().String("compression", "bzip2", "Payload compression: bzip2 or zstd")
+	cmd.Flags().Int("level", 0, "Compression level, 0 selects the default for the compression")
+
+	return cmd
+}
+
+func (z *ZpsZpkgImportCommand) setup(cmd *cobra.Command, args []string) error {
+	color, err := cmd.Flags().GetBool("no-color")
+
+	z.NoColor(color)
+
+	return err
+}
+
+func (z *ZpsZpkgImportCommand) run(cmd *cobra.Command, args []string) error {
+	image, _ := cmd.Flags().GetString("image")
+	publisher, _ := cmd.Flags().GetString("publisher")
+	name, _ := cmd.Flags().GetString("name")
+	version, _ := cmd.Flags().GetString("version")
+	workPath, _ := cmd.Flags().GetString("work-path")
+	outputPath, _ := cmd.Flags().GetString("output-path")
+	compression, _ := cmd.Flags().GetString("compression")
+	level, _ := cmd.Flags().GetInt("level")
+
+	if cmd.Flags().NArg() != 1 {
+		return errors.New("Archive filename required")
+	}
+
+	if publisher == "" {
+		return errors.New("Publisher required")
+	}
+
+	// Load manager
+	mgr, err := zpm.NewManager(image)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	SetupEventHandlers(mgr.Emitter, z.Ui)
+
+	err = mgr.ZpkgImport(cmd.Flags().Arg(0), publisher, name, version, workPath, outputPath, compression, level)
+	if err != nil {
+		z.Fatal(err.Error())
+	}
+
+	return nil
+}
@@ -26,16 +26,25 @@ import (
 )
 
 type ImageConfig struct {
-	Name string `hcl:"name"`
-	Path string `hcl:"path"`
-	Os   string `hcl:"os"`
-	Arch string `hcl:"arch"`
+	Name   string `hcl:"name"`
+	Path   string `hcl:"path"`
+	Os     string `hcl:"os"`
+	Arch   string `hcl:"arch"`
+	Policy string `hcl:"policy,optional"`
+
+	// Service manager backend for Service actions, systemd, systemd-user, memory or none
+	ServiceManager string `hcl:"service_manager,optional"`
 }
 
 type PkgConfig struct {
 	Name      string `hcl:"name,label"`
 	Operation string `hcl:"operation,optional"`
 	Version   string `hcl:"version,optional"`
+
+	// Lockfile entries additionally record where the package came from and the sha256 of the zpkg
+	Publisher string `hcl:"publisher,optional"`
+	Repo      string `hcl:"repo,optional"`
+	Digest    string `hcl:"digest,optional"`
 }
 
 type ImageFile struct {
@@ -44,6 +53,9 @@ type ImageFile struct {
 	Os   string `hcl:"os,optional"`
 	Arch string `hcl:"arch,optional"`
 
+	Policy         string `hcl:"policy,optional"`
+	ServiceManager string `hcl:"service_manager,optional"`
+
 	Repos     []*RepoConfig  `hcl:"Repo,block"`
 	Configs   []*Config      `hcl:"Config,block"`
 	Templates []*TplConfig   `hcl:"Template,block"`
@@ -124,5 +136,13 @@ func (i *ImageConfig) ToHclFile() *hclwrite.File {
 	file.Body().SetAttributeValue("os", cty.StringVal(i.Os))
 	file.Body().SetAttributeValue("arch", cty.StringVal(i.Arch))
 
+	if i.Policy != "" {
+		file.Body().SetAttributeValue("policy", cty.StringVal(i.Policy))
+	}
+
+	if i.ServiceManager != "" {
+		file.Body().SetAttributeValue("service_manager", cty.StringVal(i.ServiceManager))
+	}
+
 	return file
 }
@@ -0,0 +1,92 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package config
+
+import (
+	"io/ioutil"
+
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const DefaultLockFile = "zps.lock"
+
+// LockFile pins every package of an image to an exact id using Imagefile Package blocks
+type LockFile struct {
+	Packages []*PkgConfig `hcl:"Package,block"`
+
+	FilePath string
+}
+
+func (l *LockFile) Load(lockFilePath string) error {
+	l.FilePath = lockFilePath
+
+	if l.FilePath == "" {
+		l.FilePath = DefaultLockFile
+	}
+
+	bytes, err := ioutil.ReadFile(l.FilePath)
+	if err != nil {
+		return err
+	}
+
+	parser := hclparse.NewParser()
+
+	// Parse HCL
+	lhcl, diag := parser.ParseHCL(bytes, l.FilePath)
+	if diag.HasErrors() {
+		return diag
+	}
+
+	// Eval HCL
+	diag = gohcl.DecodeBody(lhcl.Body, nil, l)
+	if diag.HasErrors() {
+		return diag
+	}
+
+	return nil
+}
+
+// Get returns the locked entry for a package name
+func (l *LockFile) Get(name string) *PkgConfig {
+	for index := range l.Packages {
+		if l.Packages[index].Name == name {
+			return l.Packages[index]
+		}
+	}
+
+	return nil
+}
+
+func (l *LockFile) ToHclFile() *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+
+	for index, pkg := range l.Packages {
+		if index > 0 {
+			file.Body().AppendNewline()
+		}
+
+		block := file.Body().AppendNewBlock("Package", []string{pkg.Name})
+		block.Body().SetAttributeValue("operation", cty.StringVal(pkg.Operation))
+		block.Body().SetAttributeValue("version", cty.StringVal(pkg.Version))
+		block.Body().SetAttributeValue("publisher", cty.StringVal(pkg.Publisher))
+
+		if pkg.Repo != "" {
+			block.Body().SetAttributeValue("repo", cty.StringVal(pkg.Repo))
+		}
+
+		block.Body().SetAttributeValue("digest", cty.StringVal(pkg.Digest))
+	}
+
+	return file
+}
@@ -212,7 +212,7 @@ func (z *ZpsConfig) LoadImages() error {
 		defaultArch = runtime.GOARCH
 	}
 
-	defaultImage := &ImageConfig{"zroot", z.Root, defaultOs, defaultArch}
+	defaultImage := &ImageConfig{"zroot", z.Root, defaultOs, defaultArch, "", ""}
 
 	z.Images = append(z.Images, defaultImage)
 
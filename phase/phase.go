@@ -17,4 +17,5 @@ const (
 	CONFIGURE = "configure"
 	NOOP      = "noop"
 	VALIDATE  = "validate"
+	VERIFY    = "verify"
 )
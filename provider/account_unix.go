@@ -0,0 +1,277 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+// Account databases relative to the image root
+const (
+	PasswdPath  = "etc/passwd"
+	ShadowPath  = "etc/shadow"
+	GroupPath   = "etc/group"
+	GShadowPath = "etc/gshadow"
+)
+
+// AccountPaths lists every file the user and group providers may rewrite
+var AccountPaths = []string{PasswdPath, ShadowPath, GroupPath, GShadowPath}
+
+// Id ranges used when an action does not set an explicit id
+const (
+	SystemIdMin = 100
+	SystemIdMax = 999
+	IdMin       = 1000
+	IdMax       = 59999
+)
+
+type accountDb struct {
+	path    string
+	mode    os.FileMode
+	exists  bool
+	entries [][]string
+}
+
+func readAccountDb(targetPath string, dbPath string) (*accountDb, error) {
+	db := &accountDb{path: filepath.Join(targetPath, dbPath), mode: 0644}
+
+	if dbPath == ShadowPath || dbPath == GShadowPath {
+		db.mode = 0640
+	}
+
+	file, err := os.Open(db.path)
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	db.exists = true
+	db.mode = info.Mode().Perm()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		db.entries = append(db.entries, strings.Split(scanner.Text(), ":"))
+	}
+
+	return db, scanner.Err()
+}
+
+func (a *accountDb) Get(name string) []string {
+	for _, entry := range a.entries {
+		if entry[0] == name {
+			return entry
+		}
+	}
+
+	return nil
+}
+
+func (a *accountDb) Add(entry ...string) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *accountDb) Del(name string) bool {
+	for index, entry := range a.entries {
+		if entry[0] == name {
+			a.entries = append(a.entries[:index], a.entries[index+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Id returns the numeric id of an entry, both passwd and group keep it in the third field
+func (a *accountDb) Id(name string) (int, bool) {
+	entry := a.Get(name)
+	if len(entry) < 3 {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(entry[2])
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (a *accountDb) IdUsed(id int) bool {
+	for _, entry := range a.entries {
+		if len(entry) > 2 && entry[2] == strconv.Itoa(id) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Allocate picks a free id, system ids are taken from the top of the range as useradd does
+func (a *accountDb) Allocate(system bool, preferred int) (int, error) {
+	if system {
+		if preferred >= SystemIdMin && preferred <= SystemIdMax && !a.IdUsed(preferred) {
+			return preferred, nil
+		}
+
+		for id := SystemIdMax; id >= SystemIdMin; id-- {
+			if !a.IdUsed(id) {
+				return id, nil
+			}
+		}
+	} else {
+		if preferred >= IdMin && preferred <= IdMax && !a.IdUsed(preferred) {
+			return preferred, nil
+		}
+
+		for id := IdMin; id <= IdMax; id++ {
+			if !a.IdUsed(id) {
+				return id, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("no free id left in %s", a.path)
+}
+
+// Write replaces the database rather than rewriting it, the journal may hold a hard link to the original
+func (a *accountDb) Write() error {
+	err := os.MkdirAll(filepath.Dir(a.path), 0755)
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+	for _, entry := range a.entries {
+		content.WriteString(strings.Join(entry, ":"))
+		content.WriteString("\n")
+	}
+
+	tmp := a.path + ".zps"
+
+	err = ioutil.WriteFile(tmp, []byte(content.String()), a.mode)
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp, a.mode)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, a.path)
+}
+
+// AccountExists reports whether a user or group is present in the image account databases
+func AccountExists(targetPath string, account action.Action) (bool, error) {
+	dbPath := GroupPath
+	if account.Type() == "User" {
+		dbPath = PasswdPath
+	}
+
+	db, err := readAccountDb(targetPath, dbPath)
+	if err != nil {
+		return false, err
+	}
+
+	return db.Get(account.Key()) != nil, nil
+}
+
+// LookupIds resolves an owner and group against the image account databases, the host is
+// only consulted when the image is /, which also covers directory services
+func LookupIds(targetPath string, owner string, group string) (int, int, error) {
+	uid, err := lookupId(targetPath, PasswdPath, owner)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	gid, err := lookupId(targetPath, GroupPath, group)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return uid, gid, nil
+}
+
+// chownObject sets the owner and group of a path without following symlinks, an unknown
+// account is an error inside an image but falls back to root on /
+func chownObject(emitter *emission.Emitter, targetPath string, obj action.Action, path string, owner string, group string) error {
+	uid, gid, err := LookupIds(targetPath, owner, group)
+	if err != nil {
+		if filepath.Clean(targetPath) != "/" {
+			return fmt.Errorf("%s %s %s", obj.Type(), obj.Key(), err.Error())
+		}
+
+		emitter.Emit("action.warn", fmt.Sprintf("%s %s %s, owned by root", obj.Type(), obj.Key(), err.Error()))
+	}
+
+	// Only a super user can chown to another user, those failures are expected
+	os.Lchown(path, uid, gid)
+
+	return nil
+}
+
+func lookupId(targetPath string, dbPath string, name string) (int, error) {
+	db, err := readAccountDb(targetPath, dbPath)
+	if err != nil {
+		return 0, err
+	}
+
+	if id, ok := db.Id(name); ok {
+		return id, nil
+	}
+
+	// Host ids mean nothing inside another image, root is the one id every image shares
+	if filepath.Clean(targetPath) != "/" {
+		if name == "root" {
+			return 0, nil
+		}
+
+		if dbPath == PasswdPath {
+			return 0, fmt.Errorf("unknown user %s", name)
+		}
+
+		return 0, fmt.Errorf("unknown group %s", name)
+	}
+
+	if dbPath == PasswdPath {
+		usr, err := user.Lookup(name)
+		if err != nil {
+			return 0, fmt.Errorf("unknown user %s", name)
+		}
+
+		return strconv.Atoi(usr.Uid)
+	}
+
+	grp, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %s", name)
+	}
+
+	return strconv.Atoi(grp.Gid)
+}
@@ -0,0 +1,143 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+func TestAccountDbAllocate(t *testing.T) {
+	tests := []struct {
+		name      string
+		passwd    string
+		system    bool
+		preferred int
+		expected  int
+	}{
+		{
+			name:     "system from the top of the range",
+			passwd:   "root:x:0:0::/root:/bin/sh\n",
+			system:   true,
+			expected: SystemIdMax,
+		},
+		{
+			name:     "system skips used ids",
+			passwd:   "root:x:0:0::/root:/bin/sh\ndaemon:x:999:999::/:/bin/false\nbin:x:998:998::/:/bin/false\n",
+			system:   true,
+			expected: 997,
+		},
+		{
+			name:     "regular from the bottom of the range",
+			passwd:   "root:x:0:0::/root:/bin/sh\n",
+			expected: IdMin,
+		},
+		{
+			name:     "regular skips used ids",
+			passwd:   "root:x:0:0::/root:/bin/sh\nalice:x:1000:1000::/home/alice:/bin/sh\n",
+			expected: 1001,
+		},
+		{
+			name:      "preferred matching gid",
+			passwd:    "root:x:0:0::/root:/bin/sh\nalice:x:1000:1000::/home/alice:/bin/sh\n",
+			preferred: 1005,
+			expected:  1005,
+		},
+		{
+			name:      "preferred system gid",
+			passwd:    "root:x:0:0::/root:/bin/sh\n",
+			system:    true,
+			preferred: 150,
+			expected:  150,
+		},
+		{
+			name:      "preferred id taken",
+			passwd:    "root:x:0:0::/root:/bin/sh\nalice:x:1000:1000::/home/alice:/bin/sh\n",
+			preferred: 1000,
+			expected:  1001,
+		},
+		{
+			name:      "preferred id outside the range",
+			passwd:    "root:x:0:0::/root:/bin/sh\n",
+			system:    true,
+			preferred: 1005,
+			expected:  SystemIdMax,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image, err := ioutil.TempDir("", "zps-account")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(image)
+
+			err = os.MkdirAll(filepath.Join(image, "etc"), 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = ioutil.WriteFile(filepath.Join(image, PasswdPath), []byte(test.passwd), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			db, err := readAccountDb(image, PasswdPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := db.Allocate(test.system, test.preferred)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if id != test.expected {
+				t.Errorf("got %d, want %d", id, test.expected)
+			}
+		})
+	}
+}
+
+func TestChownObjectUnknownAccount(t *testing.T) {
+	image, err := ioutil.TempDir("", "zps-account")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(image)
+
+	target := filepath.Join(image, "obj")
+
+	err = ioutil.WriteFile(target, []byte("obj"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := &action.File{Path: "obj", Owner: "nobody-here", Group: "root"}
+
+	err = chownObject(emission.NewEmitter(), image, file, target, file.Owner, file.Group)
+	if err == nil {
+		t.Error("expected an unknown owner to fail inside an image")
+	}
+
+	file.Owner = "root"
+
+	err = chownObject(emission.NewEmitter(), image, file, target, file.Owner, file.Group)
+	if err != nil {
+		t.Error(err)
+	}
+}
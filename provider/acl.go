@@ -0,0 +1,202 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// POSIX.1e ACL entry tags, the values match the Linux xattr encoding
+const (
+	aclUserObj  uint16 = 0x01
+	aclUser     uint16 = 0x02
+	aclGroupObj uint16 = 0x04
+	aclGroup    uint16 = 0x08
+	aclMask     uint16 = 0x10
+	aclOther    uint16 = 0x20
+)
+
+var aclTags = map[string]uint16{
+	"user":  aclUser,
+	"u":     aclUser,
+	"group": aclGroup,
+	"g":     aclGroup,
+	"mask":  aclMask,
+	"m":     aclMask,
+	"other": aclOther,
+	"o":     aclOther,
+}
+
+var aclTagNames = map[uint16]string{
+	aclUserObj:  "user",
+	aclUser:     "user",
+	aclGroupObj: "group",
+	aclGroup:    "group",
+	aclMask:     "mask",
+	aclOther:    "other",
+}
+
+// aclEntry is a single entry, qualifier names the user or group of named entries
+type aclEntry struct {
+	tag       uint16
+	qualifier string
+	perm      uint16
+}
+
+func (a aclEntry) String() string {
+	perm := []byte("---")
+	for index, char := range "rwx" {
+		if a.perm&(4>>uint(index)) != 0 {
+			perm[index] = byte(char)
+		}
+	}
+
+	return fmt.Sprintf("%s:%s:%s", aclTagNames[a.tag], a.qualifier, perm)
+}
+
+type aclEntries []aclEntry
+
+func (a aclEntries) Len() int      { return len(a) }
+func (a aclEntries) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a aclEntries) Less(i, j int) bool {
+	if a[i].tag != a[j].tag {
+		return a[i].tag < a[j].tag
+	}
+
+	return a[i].qualifier < a[j].qualifier
+}
+
+func (a aclEntries) Strings(prefix string) []string {
+	var entries []string
+	for _, entry := range a {
+		entries = append(entries, prefix+entry.String())
+	}
+
+	return entries
+}
+
+func (a aclEntries) find(tag uint16) (aclEntry, bool) {
+	for _, entry := range a {
+		if entry.tag == tag {
+			return entry, true
+		}
+	}
+
+	return aclEntry{}, false
+}
+
+// parseAcl reads entries in the setfacl text form, [default:]user|group|mask|other:[name]:rwx,
+// default entries are returned separately
+func parseAcl(acl []string) (aclEntries, aclEntries, error) {
+	var access, defaults aclEntries
+
+	for _, text := range acl {
+		fields := strings.Split(strings.TrimSpace(text), ":")
+
+		isDefault := false
+		if len(fields) > 0 && (fields[0] == "default" || fields[0] == "d") {
+			isDefault = true
+			fields = fields[1:]
+		}
+
+		if len(fields) != 3 || len(fields[2]) > 3 {
+			return nil, nil, fmt.Errorf("invalid acl entry %s", text)
+		}
+
+		tag, ok := aclTags[fields[0]]
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid acl entry %s", text)
+		}
+
+		entry := aclEntry{tag: tag, qualifier: fields[1]}
+
+		if entry.qualifier == "" {
+			switch tag {
+			case aclUser:
+				entry.tag = aclUserObj
+			case aclGroup:
+				entry.tag = aclGroupObj
+			}
+		} else if tag == aclMask || tag == aclOther {
+			return nil, nil, fmt.Errorf("invalid acl entry %s", text)
+		}
+
+		for _, char := range fields[2] {
+			switch char {
+			case 'r':
+				entry.perm |= 4
+			case 'w':
+				entry.perm |= 2
+			case 'x':
+				entry.perm |= 1
+			case '-':
+			default:
+				return nil, nil, fmt.Errorf("invalid acl entry %s", text)
+			}
+		}
+
+		if isDefault {
+			defaults = append(defaults, entry)
+		} else {
+			access = append(access, entry)
+		}
+	}
+
+	return access, defaults, nil
+}
+
+// completeAcl adds the entries setfacl would, owner, group and other from the mode
+// and a mask covering every group class entry
+func completeAcl(entries aclEntries, mode os.FileMode) aclEntries {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	complete := append(aclEntries{}, entries...)
+
+	base := map[uint16]uint16{
+		aclUserObj:  uint16(mode>>6) & 7,
+		aclGroupObj: uint16(mode>>3) & 7,
+		aclOther:    uint16(mode) & 7,
+	}
+
+	for _, tag := range []uint16{aclUserObj, aclGroupObj, aclOther} {
+		if _, ok := complete.find(tag); !ok {
+			complete = append(complete, aclEntry{tag: tag, perm: base[tag]})
+		}
+	}
+
+	if _, ok := complete.find(aclMask); !ok {
+		var mask uint16
+		var named bool
+
+		for _, entry := range complete {
+			switch entry.tag {
+			case aclUser, aclGroup:
+				named = true
+				mask |= entry.perm
+			case aclGroupObj:
+				mask |= entry.perm
+			}
+		}
+
+		if named {
+			complete = append(complete, aclEntry{tag: aclMask, perm: mask})
+		}
+	}
+
+	sort.Sort(complete)
+
+	return complete
+}
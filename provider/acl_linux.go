@@ -0,0 +1,293 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/user"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/naegelejd/go-acl/os/group"
+	"golang.org/x/sys/unix"
+)
+
+// ACLs are read and written through the kernel xattr encoding, this avoids a cgo
+// dependency on libacl
+const (
+	aclAccessXattr  = "system.posix_acl_access"
+	aclDefaultXattr = "system.posix_acl_default"
+
+	aclVersion     = 2
+	aclUndefinedId = 0xffffffff
+)
+
+// readAcls returns the extended entries of a file in the form the Acl attribute takes,
+// owner and other are carried by the mode
+func readAcls(target string) ([]string, error) {
+	access, err := getAcl(target, aclAccessXattr)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults, err := getAcl(target, aclDefaultXattr)
+	if err != nil {
+		return nil, err
+	}
+
+	var acl []string
+
+	// Three entries are a minimal ACL, equivalent to the mode
+	if len(access) > 3 {
+		var extended aclEntries
+		for _, entry := range access {
+			if entry.tag != aclUserObj && entry.tag != aclOther {
+				extended = append(extended, aclName(entry))
+			}
+		}
+
+		acl = append(acl, extended.Strings("")...)
+	}
+
+	var named aclEntries
+	for _, entry := range defaults {
+		named = append(named, aclName(entry))
+	}
+
+	return append(acl, named.Strings("default:")...), nil
+}
+
+// writeAcls applies the access and default ACLs, names are resolved against the image
+func writeAcls(targetPath string, target string, mode os.FileMode, acl []string) error {
+	if len(acl) == 0 {
+		return nil
+	}
+
+	access, defaults, err := parseAcl(acl)
+	if err != nil {
+		return err
+	}
+
+	if len(defaults) != 0 && !mode.IsDir() {
+		return fmt.Errorf("default acl entries require a directory")
+	}
+
+	for name, entries := range map[string]aclEntries{aclAccessXattr: access, aclDefaultXattr: defaults} {
+		entries, err = resolveAcl(targetPath, completeAcl(entries, mode))
+		if err != nil {
+			return err
+		}
+
+		// Entries left over from a previous install are dropped
+		if len(entries) == 0 {
+			if name == aclDefaultXattr && !mode.IsDir() {
+				continue
+			}
+
+			err = unix.Lremovexattr(target, name)
+			if err != nil && err != unix.ENODATA {
+				return err
+			}
+
+			continue
+		}
+
+		err = unix.Lsetxattr(target, name, encodeAcl(entries), 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func verifyAcl(drift *Drift, targetPath string, target string, mode os.FileMode, acl []string) {
+	if len(acl) == 0 {
+		return
+	}
+
+	access, defaults, err := parseAcl(acl)
+	if err != nil {
+		drift.Add(fmt.Sprint("invalid manifest acl: ", err.Error()))
+		return
+	}
+
+	for name, entries := range map[string]aclEntries{aclAccessXattr: access, aclDefaultXattr: defaults} {
+		expected, err := resolveAcl(targetPath, completeAcl(entries, mode))
+		if err != nil {
+			drift.Add(fmt.Sprint("acl ", err.Error()))
+			return
+		}
+
+		actual, err := getAcl(target, name)
+		if err != nil {
+			drift.Add(fmt.Sprint("acl ", err.Error()))
+			return
+		}
+
+		// Owner and other follow the mode, which is verified on its own
+		if name == aclAccessXattr {
+			expected = aclExtended(expected)
+			actual = aclExtended(actual)
+		}
+
+		sort.Sort(expected)
+		sort.Sort(actual)
+
+		if len(expected) != len(actual) || (len(expected) != 0 && !reflect.DeepEqual(expected, actual)) {
+			if name == aclDefaultXattr {
+				drift.Add("default acl does not match manifest")
+			} else {
+				drift.Add("acl does not match manifest")
+			}
+		}
+	}
+}
+
+func aclExtended(entries aclEntries) aclEntries {
+	if len(entries) <= 3 {
+		return nil
+	}
+
+	var extended aclEntries
+	for _, entry := range entries {
+		if entry.tag != aclUserObj && entry.tag != aclOther {
+			extended = append(extended, entry)
+		}
+	}
+
+	return extended
+}
+
+// getAcl reads an ACL xattr, a missing or unsupported xattr is an empty ACL
+func getAcl(target string, name string) (aclEntries, error) {
+	size, err := unix.Lgetxattr(target, name, nil)
+	if err == unix.ENODATA || err == unix.ENOTSUP {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, size)
+	size, err = unix.Lgetxattr(target, name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := decodeAcl(data[:size])
+	if !ok {
+		return nil, fmt.Errorf("unsupported acl encoding on %s", target)
+	}
+
+	return entries, nil
+}
+
+// decodeAcl parses the kernel encoding, named entries carry the numeric id as qualifier
+func decodeAcl(data []byte) (aclEntries, bool) {
+	if len(data) < 4 || binary.LittleEndian.Uint32(data) != aclVersion || (len(data)-4)%8 != 0 {
+		return nil, false
+	}
+
+	var entries aclEntries
+	for offset := 4; offset < len(data); offset += 8 {
+		entry := aclEntry{
+			tag:  binary.LittleEndian.Uint16(data[offset:]),
+			perm: binary.LittleEndian.Uint16(data[offset+2:]),
+		}
+
+		if entry.tag == aclUser || entry.tag == aclGroup {
+			entry.qualifier = fmt.Sprint(binary.LittleEndian.Uint32(data[offset+4:]))
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, true
+}
+
+func encodeAcl(entries aclEntries) []byte {
+	// The kernel requires entries ordered by tag and then id
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].tag != entries[j].tag {
+			return entries[i].tag < entries[j].tag
+		}
+
+		left, _ := strconv.Atoi(entries[i].qualifier)
+		right, _ := strconv.Atoi(entries[j].qualifier)
+
+		return left < right
+	})
+
+	data := make([]byte, 4+8*len(entries))
+	binary.LittleEndian.PutUint32(data, aclVersion)
+
+	for index, entry := range entries {
+		offset := 4 + 8*index
+
+		id := uint64(aclUndefinedId)
+		if entry.qualifier != "" {
+			id, _ = strconv.ParseUint(entry.qualifier, 10, 32)
+		}
+
+		binary.LittleEndian.PutUint16(data[offset:], entry.tag)
+		binary.LittleEndian.PutUint16(data[offset+2:], entry.perm)
+		binary.LittleEndian.PutUint32(data[offset+4:], uint32(id))
+	}
+
+	return data
+}
+
+// resolveAcl replaces user and group names with ids from the image, numeric qualifiers are kept
+func resolveAcl(targetPath string, entries aclEntries) (aclEntries, error) {
+	var resolved aclEntries
+
+	for _, entry := range entries {
+		if entry.qualifier != "" {
+			if _, err := strconv.ParseUint(entry.qualifier, 10, 32); err != nil {
+				dbPath := PasswdPath
+				if entry.tag == aclGroup {
+					dbPath = GroupPath
+				}
+
+				id, err := lookupId(targetPath, dbPath, entry.qualifier)
+				if err != nil {
+					return nil, err
+				}
+
+				entry.qualifier = fmt.Sprint(id)
+			}
+		}
+
+		resolved = append(resolved, entry)
+	}
+
+	return resolved, nil
+}
+
+// aclName replaces an id with a name from the build host, unknown ids stay numeric
+func aclName(entry aclEntry) aclEntry {
+	switch entry.tag {
+	case aclUser:
+		if usr, err := user.LookupId(entry.qualifier); err == nil {
+			entry.qualifier = usr.Username
+		}
+	case aclGroup:
+		if grp, err := group.LookupId(entry.qualifier); err == nil {
+			entry.qualifier = grp.Name
+		}
+	}
+
+	return entry
+}
@@ -0,0 +1,148 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestAclRoundTrip(t *testing.T) {
+	// Ids unlikely to exist on the host so readAcls keeps them numeric
+	tests := []struct {
+		name     string
+		acl      []string
+		mode     os.FileMode
+		access   aclEntries
+		defaults aclEntries
+		read     []string
+	}{
+		{
+			name: "minimal",
+			acl:  []string{"user::rwx", "group::r-x", "other::r--"},
+			mode: 0754,
+			access: aclEntries{
+				{tag: aclUserObj, perm: 7},
+				{tag: aclGroupObj, perm: 5},
+				{tag: aclOther, perm: 4},
+			},
+		},
+		{
+			name: "named user",
+			acl:  []string{"user:54321:rw-"},
+			mode: 0640,
+			access: aclEntries{
+				{tag: aclUserObj, perm: 6},
+				{tag: aclUser, qualifier: "54321", perm: 6},
+				{tag: aclGroupObj, perm: 4},
+				{tag: aclMask, perm: 6},
+				{tag: aclOther},
+			},
+			read: []string{"user:54321:rw-", "group::r--", "mask::rw-"},
+		},
+		{
+			name: "explicit mask",
+			acl:  []string{"g:54322:rwx", "m::r-x"},
+			mode: 0750,
+			access: aclEntries{
+				{tag: aclUserObj, perm: 7},
+				{tag: aclGroupObj, perm: 5},
+				{tag: aclGroup, qualifier: "54322", perm: 7},
+				{tag: aclMask, perm: 5},
+				{tag: aclOther},
+			},
+			read: []string{"group::r-x", "group:54322:rwx", "mask::r-x"},
+		},
+		{
+			name: "default entries",
+			acl:  []string{"default:user::rwx", "default:group:54322:r-x", "d:other::---"},
+			mode: os.ModeDir | 0750,
+			defaults: aclEntries{
+				{tag: aclUserObj, perm: 7},
+				{tag: aclGroupObj, perm: 5},
+				{tag: aclGroup, qualifier: "54322", perm: 5},
+				{tag: aclMask, perm: 5},
+				{tag: aclOther},
+			},
+			read: []string{"default:user::rwx", "default:group::r-x", "default:group:54322:r-x", "default:mask::r-x", "default:other::---"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			access, defaults, err := parseAcl(test.acl)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, set := range []struct {
+				name     string
+				entries  aclEntries
+				expected aclEntries
+			}{{"access", access, test.access}, {"default", defaults, test.defaults}} {
+				decoded, ok := decodeAcl(encodeAcl(completeAcl(set.entries, test.mode)))
+				if !ok {
+					t.Fatalf("%s: encoding not decodable", set.name)
+				}
+
+				sort.Sort(decoded)
+				sort.Sort(set.expected)
+
+				if len(set.expected) == 0 && len(decoded) == 0 {
+					continue
+				}
+
+				if !reflect.DeepEqual(decoded, set.expected) {
+					t.Errorf("%s: got %v, want %v", set.name, decoded, set.expected)
+				}
+			}
+
+			image, err := ioutil.TempDir("", "zps-acl")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(image)
+
+			target := filepath.Join(image, "obj")
+			if test.mode.IsDir() {
+				err = os.Mkdir(target, test.mode.Perm())
+			} else {
+				err = ioutil.WriteFile(target, nil, test.mode.Perm())
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = writeAcls(image, target, test.mode, test.acl)
+			if err == unix.ENOTSUP || err == unix.EPERM {
+				t.Skip("acls are not supported on ", image)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			read, err := readAcls(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(read) != len(test.read) || (len(read) != 0 && !reflect.DeepEqual(read, test.read)) {
+				t.Errorf("read: got %v, want %v", read, test.read)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !linux
+// +build !linux
+
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"errors"
+	"os"
+)
+
+func readAcls(target string) ([]string, error) {
+	return nil, nil
+}
+
+func writeAcls(targetPath string, target string, mode os.FileMode, acl []string) error {
+	if len(acl) == 0 {
+		return nil
+	}
+
+	return errors.New("posix acls are not supported on this platform")
+}
+
+func verifyAcl(drift *Drift, targetPath string, target string, mode os.FileMode, acl []string) {}
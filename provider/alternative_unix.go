@@ -0,0 +1,107 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/chuckpreslar/emission"
+
+	"github.com/fezz-io/zps/action"
+)
+
+type AlternativeUnix struct {
+	*emission.Emitter
+	alternative *action.Alternative
+
+	phaseMap map[string]string
+}
+
+func NewAlternativeUnix(alternative action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &AlternativeUnix{emitter, alternative.(*action.Alternative), phaseMap}
+}
+
+func (a *AlternativeUnix) Realize(ctx context.Context) error {
+	switch a.phaseMap[Phase(ctx)] {
+	case "install":
+		return a.install(ctx)
+	case "remove":
+		return a.remove(ctx)
+	default:
+		a.Emit("action.info", fmt.Sprintf("%s %s", a.alternative.Type(), a.alternative.Key()))
+		return nil
+	}
+}
+
+// install points the link at the target of this alternative, replacing the previous owner
+func (a *AlternativeUnix) install(ctx context.Context) error {
+	options := Opts(ctx)
+	link, err := safePath(options.TargetPath, a.alternative, a.alternative.Link)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(link)
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s %s: not a symlink, refusing to replace", a.alternative.Type(), a.alternative.Key())
+	}
+
+	target := a.target()
+	if current, err := os.Readlink(link); err == nil && current == target {
+		return nil
+	}
+
+	a.Emit("action.info", fmt.Sprintf("%s %s -> %s", a.alternative.Type(), a.alternative.Key(), a.alternative.Target))
+
+	os.Remove(link)
+
+	err = os.MkdirAll(path.Dir(link), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Symlink(target, link)
+}
+
+// remove drops the link if it still points at the target of this alternative
+func (a *AlternativeUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	link, err := safePath(options.TargetPath, a.alternative, a.alternative.Link)
+	if err != nil {
+		return err
+	}
+
+	current, err := os.Readlink(link)
+	if err != nil || current != a.target() {
+		return nil
+	}
+
+	err = os.Remove(link)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// target is relative to the link so it resolves both inside and outside the image
+func (a *AlternativeUnix) target() string {
+	target, err := filepath.Rel(path.Dir(path.Clean(a.alternative.Link)), path.Clean(a.alternative.Target))
+	if err != nil {
+		return "/" + path.Clean(a.alternative.Target)
+	}
+
+	return target
+}
@@ -12,9 +12,11 @@ package provider
 
 import (
 	"context"
+	"os"
 	"runtime"
 
 	"github.com/fezz-io/zps/phase"
+	"github.com/fezz-io/zps/systemd"
 
 	"github.com/chuckpreslar/emission"
 	"github.com/fezz-io/zps/action"
@@ -33,6 +35,9 @@ type Options struct {
 	Secure   bool
 	Restrict bool
 
+	// Capture POSIX ACLs of packaged files and directories
+	Acls bool
+
 	Owner string
 	Group string
 
@@ -44,6 +49,10 @@ type Factory struct {
 	phaseMap    map[string]map[string]string
 	providerMap map[string]func(action.Action, map[string]string, *emission.Emitter) Provider
 	emitter     *emission.Emitter
+
+	// Named alternatives to the default provider of an action and the ones selected
+	alternates map[string]map[string]func(action.Action, map[string]string, *emission.Emitter) Provider
+	selected   map[string]string
 }
 
 func New(emitter *emission.Emitter) *Factory {
@@ -51,13 +60,20 @@ func New(emitter *emission.Emitter) *Factory {
 		make(map[string]map[string]string),
 		make(map[string]func(action.Action, map[string]string, *emission.Emitter) Provider),
 		emitter,
+		make(map[string]map[string]func(action.Action, map[string]string, *emission.Emitter) Provider),
+		make(map[string]string),
 	}
 }
 
-// Need to add provider switching
-// for now defaults will work on all OSs we care about
+// Get returns the selected provider for an action, or the registered default
 func (f *Factory) Get(ac action.Action) Provider {
-	return f.providerMap[ac.Type()](ac, f.phaseMap[ac.Type()], f.emitter)
+	newFunc := f.providerMap[ac.Type()]
+
+	if name, ok := f.selected[ac.Type()]; ok {
+		newFunc = f.alternates[ac.Type()][name]
+	}
+
+	return newFunc(ac, f.phaseMap[ac.Type()], f.emitter)
 }
 
 // Build phase map
@@ -77,6 +93,32 @@ func (f *Factory) Register(provider string, newFunc func(action.Action, map[stri
 	return f
 }
 
+// Provide registers a named alternative provider for an action
+func (f *Factory) Provide(provider string, name string, newFunc func(action.Action, map[string]string, *emission.Emitter) Provider) *Factory {
+	if f.alternates[provider] == nil {
+		f.alternates[provider] = make(map[string]func(action.Action, map[string]string, *emission.Emitter) Provider)
+	}
+	f.alternates[provider][name] = newFunc
+
+	return f
+}
+
+// Provides reports whether a named provider is registered for an action
+func (f *Factory) Provides(action string, name string) bool {
+	_, ok := f.alternates[action][name]
+
+	return ok
+}
+
+// Use selects a named provider for an action, unknown names leave the default in place
+func (f *Factory) Use(action string, name string) *Factory {
+	if f.Provides(action, name) {
+		f.selected[action] = name
+	}
+
+	return f
+}
+
 func Phase(ctx context.Context) string {
 	return ctx.Value("phase").(string)
 }
@@ -89,32 +131,76 @@ func DefaultFactory(emitter *emission.Emitter) *Factory {
 	factory := New(emitter)
 
 	factory.
+		Register("Alternative", NewAlternativeUnix).
 		Register("Dir", NewDirUnix).
+		Register("Divert", NewDivertUnix).
 		Register("File", NewFileUnix).
+		Register("Group", NewGroupUnix).
+		Register("HardLink", NewHardLinkUnix).
+		Register("Hook", NewHookDefault).
+		Register("Node", NewNodeUnix).
 		Register("Requirement", NewRequirementDefault).
 		Register("SymLink", NewSymLinkUnix).
 		Register("Tag", NewTagDefault).
 		Register("Template", NewTemplateDefault).
+		Register("Trigger", NewTriggerDefault).
+		Register("User", NewUserUnix).
 		Register("Zpkg", NewZpkgDefault)
 
 	factory.
+		On("Alternative", phase.INSTALL, "install").
+		On("Alternative", phase.REMOVE, "remove").
 		On("Dir", phase.INSTALL, "install").
 		On("Dir", phase.PACKAGE, "package").
 		On("Dir", phase.REMOVE, "remove").
+		On("Dir", phase.VERIFY, "verify").
+		On("Divert", phase.INSTALL, "install").
+		On("Divert", phase.REMOVE, "remove").
 		On("File", phase.INSTALL, "install").
 		On("File", phase.PACKAGE, "package").
 		On("File", phase.REMOVE, "remove").
 		On("File", phase.VALIDATE, "validate").
+		On("File", phase.VERIFY, "verify").
+		On("Group", phase.INSTALL, "install").
+		On("Group", phase.REMOVE, "remove").
+		On("HardLink", phase.INSTALL, "install").
+		On("HardLink", phase.PACKAGE, "package").
+		On("HardLink", phase.REMOVE, "remove").
+		On("HardLink", phase.VERIFY, "verify").
+		On("Hook", phase.INSTALL, "run").
+		On("Hook", phase.REMOVE, "run").
+		On("Node", phase.INSTALL, "install").
+		On("Node", phase.PACKAGE, "package").
+		On("Node", phase.REMOVE, "remove").
+		On("Node", phase.VERIFY, "verify").
 		On("SymLink", phase.INSTALL, "install").
 		On("SymLink", phase.PACKAGE, "package").
 		On("SymLink", phase.REMOVE, "remove").
-		On("Template", phase.CONFIGURE, "configure")
+		On("SymLink", phase.VERIFY, "verify").
+		On("Template", phase.CONFIGURE, "configure").
+		On("Trigger", phase.INSTALL, "run").
+		On("User", phase.INSTALL, "install").
+		On("User", phase.REMOVE, "remove")
+
+	// Service managers are selected per image, the default follows the platform and user
+	factory.
+		Provide("Service", "memory", NewServiceUnit(NewMemoryServiceManager())).
+		Provide("Service", "none", NewServiceDefault).
+		On("Service", phase.CONFIGURE, "configure").
+		On("Service", phase.INSTALL, "install").
+		On("Service", phase.REMOVE, "remove")
 
 	switch runtime.GOOS {
 	case "linux":
-		factory.Register("Service", NewServiceSystemD)
-		factory.On("Service", phase.CONFIGURE, "configure")
-		factory.On("Service", phase.REMOVE, "remove")
+		factory.
+			Provide("Service", "systemd", NewServiceUnit(&systemd.Systemctl{})).
+			Provide("Service", "systemd-user", NewServiceUnit(&systemd.Systemctl{User: true}))
+
+		if os.Geteuid() == 0 {
+			factory.Register("Service", NewServiceUnit(&systemd.Systemctl{}))
+		} else {
+			factory.Register("Service", NewServiceUnit(&systemd.Systemctl{User: true}))
+		}
 	default:
 		factory.Register("Service", NewServiceDefault)
 	}
@@ -47,6 +47,8 @@ func (d *DirUnix) Realize(ctx context.Context) error {
 		return d.pkg(ctx)
 	case "remove":
 		return d.remove(ctx)
+	case "verify":
+		return d.verify(ctx)
 	default:
 		return nil
 	}
@@ -54,7 +56,10 @@ func (d *DirUnix) Realize(ctx context.Context) error {
 
 func (d *DirUnix) install(ctx context.Context) error {
 	options := Opts(ctx)
-	target := path.Join(options.TargetPath, d.dir.Path)
+	target, err := safePath(options.TargetPath, d.dir, d.dir.Path)
+	if err != nil {
+		return err
+	}
 
 	mode, err := strconv.ParseUint(d.dir.Mode, 0, 0)
 	if err != nil {
@@ -67,22 +72,21 @@ func (d *DirUnix) install(ctx context.Context) error {
 		return err
 	}
 
-	// Silent failures are fine, only a super user can chown to another user
-	// Also a given user may not exist on a system though we should catch
-	// that elsewhere
+	err = chownObject(d.Emitter, options.TargetPath, d.dir, target, d.dir.Owner, d.dir.Group)
+	if err != nil {
+		return err
+	}
 
-	owner, _ := user.Lookup(d.dir.Owner)
-	grp, _ := user.LookupGroup(d.dir.Group)
-	var uid int64
-	var gid int64
+	err = writeAcls(options.TargetPath, target, os.ModeDir|os.FileMode(mode), d.dir.Acl)
+	if err != nil {
+		// Most file systems allow owners to set ACLs, failures outside a super user are reported only
+		if os.Geteuid() == 0 {
+			return err
+		}
 
-	if owner != nil && grp != nil {
-		uid, _ = strconv.ParseInt(owner.Uid, 0, 0)
-		gid, _ = strconv.ParseInt(grp.Gid, 0, 0)
+		d.Emit("action.warn", fmt.Sprintf("%s %s acl not set: %s", d.dir.Type(), d.dir.Key(), err.Error()))
 	}
 
-	os.Chown(target, int(uid), int(gid))
-
 	return nil
 }
 
@@ -127,12 +131,56 @@ func (d *DirUnix) pkg(ctx context.Context) error {
 		}
 	}
 
-	return err
+	if d.dir.Acl == nil && options.Acls {
+		d.dir.Acl, err = readAcls(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _, err = parseAcl(d.dir.Acl)
+	if err != nil {
+		return fmt.Errorf("%s %s: %s", d.dir.Type(), d.dir.Key(), err.Error())
+	}
+
+	return nil
 }
 
-func (d *DirUnix) remove(ctx context.Context) error {
+func (d *DirUnix) verify(ctx context.Context) error {
 	options := Opts(ctx)
 	target := path.Join(options.TargetPath, d.dir.Path)
+	drift := &Drift{}
+
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		drift.Add("missing")
+		return drift
+	}
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		drift.Add("not a directory")
+		return drift
+	}
+
+	verifyMode(drift, info, d.dir.Mode)
+	verifyOwnership(drift, info, options.TargetPath, d.dir.Owner, d.dir.Group)
+
+	if mode, err := strconv.ParseUint(d.dir.Mode, 0, 0); err == nil {
+		verifyAcl(drift, options.TargetPath, target, os.FileMode(mode), d.dir.Acl)
+	}
+
+	return drift.Result()
+}
+
+func (d *DirUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, d.dir, d.dir.Path)
+	if err != nil {
+		return err
+	}
 
 	empty, err := d.isEmpty(target)
 	if err != nil {
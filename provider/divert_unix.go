@@ -0,0 +1,114 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/chuckpreslar/emission"
+
+	"github.com/fezz-io/zps/action"
+)
+
+type DivertUnix struct {
+	*emission.Emitter
+	divert *action.Divert
+
+	phaseMap map[string]string
+}
+
+func NewDivertUnix(divert action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &DivertUnix{emitter, divert.(*action.Divert), phaseMap}
+}
+
+func (d *DivertUnix) Realize(ctx context.Context) error {
+	switch d.phaseMap[Phase(ctx)] {
+	case "install":
+		return d.install(ctx)
+	case "remove":
+		return d.remove(ctx)
+	default:
+		d.Emit("action.info", fmt.Sprintf("%s %s", d.divert.Type(), d.divert.Key()))
+		return nil
+	}
+}
+
+// install moves a file already installed at the path to the diverted location
+func (d *DivertUnix) install(ctx context.Context) error {
+	options := Opts(ctx)
+	source, destination, err := d.paths(options.TargetPath)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Lstat(source)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		return fmt.Errorf("%s %s: cannot divert a directory", d.divert.Type(), d.divert.Key())
+	}
+
+	if _, err := os.Lstat(destination); err == nil {
+		return fmt.Errorf("%s %s: %s already exists", d.divert.Type(), d.divert.Key(), d.divert.To)
+	}
+
+	d.Emit("action.info", fmt.Sprintf("%s %s -> %s", d.divert.Type(), d.divert.Key(), d.divert.To))
+
+	err = os.MkdirAll(path.Dir(destination), 0755)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(source, destination)
+}
+
+// remove moves a diverted file back, the path must have been cleared first
+func (d *DivertUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	source, destination, err := d.paths(options.TargetPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Lstat(destination); os.IsNotExist(err) {
+		return nil
+	}
+
+	if _, err := os.Lstat(source); err == nil {
+		return fmt.Errorf("%s %s: path is in use, cannot restore %s", d.divert.Type(), d.divert.Key(), d.divert.To)
+	}
+
+	d.Emit("action.info", fmt.Sprintf("%s %s <- %s", d.divert.Type(), d.divert.Key(), d.divert.To))
+
+	return os.Rename(destination, source)
+}
+
+func (d *DivertUnix) paths(targetPath string) (string, string, error) {
+	source, err := safePath(targetPath, d.divert, d.divert.Path)
+	if err != nil {
+		return "", "", err
+	}
+
+	destination, err := safePath(targetPath, d.divert, d.divert.To)
+	if err != nil {
+		return "", "", err
+	}
+
+	return source, destination, nil
+}
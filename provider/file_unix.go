@@ -50,6 +50,8 @@ func (f *FileUnix) Realize(ctx context.Context) error {
 		return f.remove(ctx)
 	case "validate":
 		return f.validate(ctx)
+	case "verify":
+		return f.verify(ctx)
 	default:
 		return nil
 	}
@@ -59,19 +61,36 @@ func (f *FileUnix) install(ctx context.Context) error {
 	options := Opts(ctx)
 	payload := ctx.Value("payload").(*zpayload.Reader)
 
-	target := path.Join(options.TargetPath, f.file.Path)
+	target, err := safePath(options.TargetPath, f.file, f.file.Path)
+	if err != nil {
+		return err
+	}
 
 	mode, err := strconv.ParseUint(f.file.Mode, 0, 0)
 	if err != nil {
 		return err
 	}
 
+	// Modified config files are left in place, the new version is written alongside
+	if f.file.Config {
+		modified, err := f.modified(target, previousFile(ctx, f.file.Path))
+		if err != nil {
+			return err
+		}
+
+		if modified {
+			target = target + ".zpsnew"
+			f.Emit("action.warn", fmt.Sprintf("%s %s modified locally, new version installed as %s.zpsnew", f.file.Type(), f.file.Key(), f.file.Key()))
+		}
+	}
+
+	// Unlink rather than rewrite, the journal may hold a hard link to the original
+	os.Remove(target)
+
 	if f.file.Size != 0 {
 		var digest string
 		var err error
 
-		os.Remove(target)
-
 		digest, err = payload.Get(target, int64(f.file.Offset), int64(f.file.Size))
 		if err != nil {
 			return err
@@ -89,22 +108,32 @@ func (f *FileUnix) install(ctx context.Context) error {
 		file.Close()
 	}
 
-	// Silent failures are fine, only a super user can chown to another user
-	// Also a given user may not exist on a system though we should catch
-	// that elsewhere
+	err = chownObject(f.Emitter, options.TargetPath, f.file, target, f.file.Owner, f.file.Group)
+	if err != nil {
+		return err
+	}
+
+	os.Chmod(target, os.FileMode(mode))
 
-	owner, _ := user.Lookup(f.file.Owner)
-	grp, _ := user.LookupGroup(f.file.Group)
-	var uid int64
-	var gid int64
+	// Set last, a chown clears file capabilities
+	err = writeXattrs(target, f.file.Xattrs)
+	if err != nil {
+		// Capabilities and most security attributes require a super user
+		if os.Geteuid() == 0 {
+			return err
+		}
 
-	if owner != nil && grp != nil {
-		uid, _ = strconv.ParseInt(owner.Uid, 0, 0)
-		gid, _ = strconv.ParseInt(grp.Gid, 0, 0)
+		f.Emit("action.warn", fmt.Sprintf("%s %s xattrs not set: %s", f.file.Type(), f.file.Key(), err.Error()))
 	}
 
-	os.Chown(target, int(uid), int(gid))
-	os.Chmod(target, os.FileMode(mode))
+	err = writeAcls(options.TargetPath, target, os.FileMode(mode), f.file.Acl)
+	if err != nil {
+		if os.Geteuid() == 0 {
+			return err
+		}
+
+		f.Emit("action.warn", fmt.Sprintf("%s %s acl not set: %s", f.file.Type(), f.file.Key(), err.Error()))
+	}
 
 	return nil
 }
@@ -152,6 +181,25 @@ func (f *FileUnix) pkg(ctx context.Context) error {
 		}
 	}
 
+	if f.file.Xattrs == nil {
+		f.file.Xattrs, err = readXattrs(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.file.Acl == nil && options.Acls {
+		f.file.Acl, err = readAcls(target)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, _, err = parseAcl(f.file.Acl)
+	if err != nil {
+		return fmt.Errorf("%s %s: %s", f.file.Type(), f.file.Key(), err.Error())
+	}
+
 	f.file.Size = int(info.Size())
 
 	// Add to payload
@@ -190,14 +238,153 @@ func (f *FileUnix) validate(ctx context.Context) error {
 	return err
 }
 
-func (f *FileUnix) remove(ctx context.Context) error {
+func (f *FileUnix) verify(ctx context.Context) error {
 	options := Opts(ctx)
 	target := path.Join(options.TargetPath, f.file.Path)
+	drift := &Drift{}
+
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		drift.Add("missing")
+		return drift
+	}
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		drift.Add("not a regular file")
+		return drift
+	}
+
+	verifyMode(drift, info, f.file.Mode)
+	verifyOwnership(drift, info, options.TargetPath, f.file.Owner, f.file.Group)
+
+	if mode, err := strconv.ParseUint(f.file.Mode, 0, 0); err == nil {
+		verifyAcl(drift, options.TargetPath, target, os.FileMode(mode), f.file.Acl)
+	}
+
+	xattrs, err := readXattrs(target)
+	if err != nil {
+		return err
+	}
+
+	for name, value := range f.file.Xattrs {
+		if xattrs[name] != value {
+			drift.Add(fmt.Sprintf("xattr %s does not match manifest", name))
+		}
+	}
 
-	err := os.Remove(target)
+	// Local edits to config files are expected
+	if f.file.Config {
+		return drift.Result()
+	}
+
+	if info.Size() != int64(f.file.Size) {
+		drift.Add(fmt.Sprintf("size %d != %d", info.Size(), f.file.Size))
+	} else if f.file.Size != 0 {
+		digest, err := fileDigest(target)
+		if err != nil {
+			return err
+		}
+
+		if digest != f.file.Digest {
+			drift.Add("digest does not match manifest")
+		}
+	}
+
+	return drift.Result()
+}
+
+func (f *FileUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, f.file, f.file.Path)
+	if err != nil {
+		return err
+	}
+
+	if f.file.Config {
+		// Upgrades leave config files to the install of the next version
+		if next := nextFile(ctx, f.file.Path); next != nil && next.Config {
+			return nil
+		}
+
+		modified, err := f.modified(target, f.file)
+		if err != nil {
+			return err
+		}
+
+		if modified {
+			f.Emit("action.warn", fmt.Sprintf("%s %s modified locally, saved as %s.zpssave", f.file.Type(), f.file.Key(), f.file.Key()))
+			return os.Rename(target, target+".zpssave")
+		}
+	}
+
+	err = os.Remove(target)
 	if os.IsNotExist(err) {
 		return nil
 	}
 
 	return err
 }
+
+// modified reports whether an existing file differs from both this file and the installed version
+func (f *FileUnix) modified(target string, installed *action.File) (bool, error) {
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	var digest string
+	if info.Size() != 0 {
+		digest, err = fileDigest(target)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if digest == f.file.Digest {
+		return false, nil
+	}
+
+	if installed != nil && digest == installed.Digest {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// previousFile looks up a path in the manifest of the version being upgraded from
+func previousFile(ctx context.Context, filePath string) *action.File {
+	manifest, _ := ctx.Value("previous").(*action.Manifest)
+
+	return manifestFile(manifest, filePath)
+}
+
+// nextFile looks up a path in the manifest of the version being upgraded to
+func nextFile(ctx context.Context, filePath string) *action.File {
+	manifest, _ := ctx.Value("next").(*action.Manifest)
+
+	return manifestFile(manifest, filePath)
+}
+
+func manifestFile(manifest *action.Manifest, filePath string) *action.File {
+	if manifest == nil {
+		return nil
+	}
+
+	for _, file := range manifest.Files {
+		if file.Path == filePath {
+			return file
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,192 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuckpreslar/emission"
+
+	"github.com/fezz-io/zps/action"
+	zpayload "github.com/fezz-io/zps/zpkg/payload"
+)
+
+func TestFileUnixConfig(t *testing.T) {
+	digest := func(content string) string {
+		sum := sha256.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+
+	config := func(content string) *action.File {
+		return &action.File{Path: "app.conf", Owner: "root", Group: "root", Mode: "0644", Size: len(content), Digest: digest(content), Config: true}
+	}
+
+	manifest := func(file *action.File) *action.Manifest {
+		return &action.Manifest{Files: []*action.File{file}}
+	}
+
+	plain := config("old")
+	plain.Config = false
+
+	tests := []struct {
+		name     string
+		remove   bool
+		file     *action.File
+		existing string
+		previous *action.Manifest
+		next     *action.Manifest
+
+		// Expected contents, empty for a missing file
+		target  string
+		zpsnew  string
+		zpssave string
+		warned  bool
+	}{
+		{
+			name:   "install",
+			file:   config("new"),
+			target: "new",
+		},
+		{
+			name:     "upgrade unmodified",
+			file:     config("new"),
+			existing: "old",
+			previous: manifest(config("old")),
+			target:   "new",
+		},
+		{
+			name:     "upgrade modified",
+			file:     config("new"),
+			existing: "edited",
+			previous: manifest(config("old")),
+			target:   "edited",
+			zpsnew:   "new",
+			warned:   true,
+		},
+		{
+			name:     "upgrade already current",
+			file:     config("new"),
+			existing: "new",
+			previous: manifest(config("old")),
+			target:   "new",
+		},
+		{
+			name:     "remove unmodified",
+			remove:   true,
+			file:     config("old"),
+			existing: "old",
+		},
+		{
+			name:     "remove modified",
+			remove:   true,
+			file:     config("old"),
+			existing: "edited",
+			zpssave:  "edited",
+			warned:   true,
+		},
+		{
+			name:     "upgrade skips remove",
+			remove:   true,
+			file:     config("old"),
+			existing: "edited",
+			next:     manifest(config("new")),
+			target:   "edited",
+		},
+		{
+			name:     "remove plain file",
+			remove:   true,
+			file:     plain,
+			existing: "edited",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image, err := ioutil.TempDir("", "zps-file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(image)
+
+			work, err := ioutil.TempDir("", "zps-work")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(work)
+
+			// The payload carries the content the file action was built from
+			source := filepath.Join(work, "source")
+			err = ioutil.WriteFile(source, []byte("new"), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			writer := zpayload.NewWriter(work, 0)
+			test.file.Offset, test.file.Csize, _, err = writer.Put(source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writer.Close()
+
+			target := filepath.Join(image, "app.conf")
+			if test.existing != "" {
+				err = ioutil.WriteFile(target, []byte(test.existing), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ctx := context.WithValue(context.Background(), "options", &Options{TargetPath: image})
+			ctx = context.WithValue(ctx, "payload", zpayload.NewReader(work, writer.Name(), 0))
+			ctx = context.WithValue(ctx, "previous", test.previous)
+			ctx = context.WithValue(ctx, "next", test.next)
+
+			warned := false
+			emitter := emission.NewEmitter()
+			emitter.On("action.warn", func(message string) { warned = true })
+
+			file := NewFileUnix(test.file, nil, emitter).(*FileUnix)
+			if test.remove {
+				err = file.remove(ctx)
+			} else {
+				err = file.install(ctx)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for path, expected := range map[string]string{target: test.target, target + ".zpsnew": test.zpsnew, target + ".zpssave": test.zpssave} {
+				content, err := ioutil.ReadFile(path)
+				if expected == "" {
+					if !os.IsNotExist(err) {
+						t.Errorf("%s: expected no file, got %q", filepath.Base(path), content)
+					}
+
+					continue
+				}
+
+				if err != nil || string(content) != expected {
+					t.Errorf("%s: got %q, %v, want %q", filepath.Base(path), content, err, expected)
+				}
+			}
+
+			if warned != test.warned {
+				t.Errorf("warned: got %v, want %v", warned, test.warned)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+type GroupUnix struct {
+	*emission.Emitter
+	group *action.Group
+
+	phaseMap map[string]string
+}
+
+func NewGroupUnix(group action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &GroupUnix{emitter, group.(*action.Group), phaseMap}
+}
+
+func (g *GroupUnix) Realize(ctx context.Context) error {
+	switch g.phaseMap[Phase(ctx)] {
+	case "install":
+		return g.install(ctx)
+	case "remove":
+		return g.remove(ctx)
+	default:
+		g.Emit("action.info", fmt.Sprintf("%s %s", g.group.Type(), g.group.Key()))
+		return nil
+	}
+}
+
+// Existing groups are left as they are, they may have been created by an administrator
+func (g *GroupUnix) install(ctx context.Context) error {
+	options := Opts(ctx)
+
+	groups, err := readAccountDb(options.TargetPath, GroupPath)
+	if err != nil {
+		return err
+	}
+
+	if groups.Get(g.group.Name) != nil {
+		return nil
+	}
+
+	gid := g.group.Gid
+	if gid == 0 {
+		gid, err = groups.Allocate(g.group.System, 0)
+		if err != nil {
+			return err
+		}
+	} else if groups.IdUsed(gid) {
+		return fmt.Errorf("gid %d for group %s is already in use", gid, g.group.Name)
+	}
+
+	groups.Add(g.group.Name, "x", strconv.Itoa(gid), "")
+
+	err = groups.Write()
+	if err != nil {
+		return err
+	}
+
+	gshadow, err := readAccountDb(options.TargetPath, GShadowPath)
+	if err != nil {
+		return err
+	}
+
+	if gshadow.exists && gshadow.Get(g.group.Name) == nil {
+		gshadow.Add(g.group.Name, "!", "", "")
+
+		err = gshadow.Write()
+		if err != nil {
+			return err
+		}
+	}
+
+	g.Emit("action.info", fmt.Sprintf("%s %s gid %d", g.group.Type(), g.group.Key(), gid))
+
+	return nil
+}
+
+// Transactions only remove accounts zps created, existing ones are never passed here
+func (g *GroupUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+
+	for _, dbPath := range []string{GShadowPath, GroupPath} {
+		db, err := readAccountDb(options.TargetPath, dbPath)
+		if err != nil {
+			return err
+		}
+
+		if db.Del(g.group.Name) {
+			err = db.Write()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	g.Emit("action.info", fmt.Sprintf("%s %s removed", g.group.Type(), g.group.Key()))
+
+	return nil
+}
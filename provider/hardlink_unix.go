@@ -0,0 +1,136 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+type HardLinkUnix struct {
+	*emission.Emitter
+	hardlink *action.HardLink
+
+	phaseMap map[string]string
+}
+
+func NewHardLinkUnix(hardlink action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &HardLinkUnix{emitter, hardlink.(*action.HardLink), phaseMap}
+}
+
+func (h *HardLinkUnix) Realize(ctx context.Context) error {
+	switch h.phaseMap[Phase(ctx)] {
+	case "install":
+		return h.install(ctx)
+	case "package":
+		h.Emit("action.info", fmt.Sprintf("%s %s", h.hardlink.Type(), h.hardlink.Key()))
+		return h.pkg(ctx)
+	case "remove":
+		return h.remove(ctx)
+	case "verify":
+		return h.verify(ctx)
+	default:
+		return nil
+	}
+}
+
+// Hard links are installed after files, the file they name is already in place
+func (h *HardLinkUnix) install(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, h.hardlink, h.hardlink.Path)
+	if err != nil {
+		return err
+	}
+
+	source, err := safePath(options.TargetPath, h.hardlink, h.hardlink.Target)
+	if err != nil {
+		return err
+	}
+
+	// Unlink rather than rewrite, the journal may hold a hard link to the original
+	os.Remove(target)
+
+	return os.Link(source, target)
+}
+
+func (h *HardLinkUnix) pkg(ctx context.Context) error {
+	options := Opts(ctx)
+
+	same, err := h.linked(options.TargetPath)
+	if err != nil {
+		return err
+	}
+
+	if !same {
+		return fmt.Errorf("%s is not a hard link to %s", h.hardlink.Path, h.hardlink.Target)
+	}
+
+	return nil
+}
+
+func (h *HardLinkUnix) verify(ctx context.Context) error {
+	options := Opts(ctx)
+	drift := &Drift{}
+
+	_, err := os.Lstat(path.Join(options.TargetPath, h.hardlink.Path))
+	if os.IsNotExist(err) {
+		drift.Add("missing")
+		return drift
+	}
+
+	same, err := h.linked(options.TargetPath)
+	if err != nil {
+		return err
+	}
+
+	if !same {
+		drift.Add(fmt.Sprintf("not linked to %s", h.hardlink.Target))
+	}
+
+	return drift.Result()
+}
+
+func (h *HardLinkUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, h.hardlink, h.hardlink.Path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (h *HardLinkUnix) linked(targetPath string) (bool, error) {
+	info, err := os.Lstat(path.Join(targetPath, h.hardlink.Path))
+	if err != nil {
+		return false, err
+	}
+
+	target, err := os.Lstat(path.Join(targetPath, h.hardlink.Target))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return os.SameFile(info, target), nil
+}
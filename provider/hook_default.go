@@ -0,0 +1,61 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+type HookDefault struct {
+	*emission.Emitter
+	hook *action.Hook
+
+	phaseMap map[string]string
+}
+
+func NewHookDefault(hook action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &HookDefault{emitter, hook.(*action.Hook), phaseMap}
+}
+
+func (h *HookDefault) Realize(ctx context.Context) error {
+	switch h.phaseMap[Phase(ctx)] {
+	case "run":
+		return h.run(ctx)
+	default:
+		h.Emit("action.info", fmt.Sprintf("%s %s", h.hook.Type(), h.hook.Key()))
+		return nil
+	}
+}
+
+func (h *HookDefault) run(ctx context.Context) error {
+	h.Emit("action.info", fmt.Sprintf("%s %s %s", h.hook.Type(), h.hook.Phase, h.hook.Key()))
+
+	return runCommand(ctx, h.Emitter, h.hook, h.hook.Name, h.hook.Command, h.hook.Timeout, h.env(ctx))
+}
+
+// env describes the package and image to the hook, hook defined variables are applied last
+func (h *HookDefault) env(ctx context.Context) []string {
+	env := append(shellEnv(ctx), "ZPS_HOOK="+h.hook.Phase)
+
+	if previous, ok := ctx.Value("previous").(*action.Manifest); ok && previous != nil {
+		env = append(env, "ZPS_PREVIOUS_VERSION="+previous.Zpkg.Version)
+	}
+
+	for key, value := range h.hook.Env {
+		env = append(env, key+"="+value)
+	}
+
+	return env
+}
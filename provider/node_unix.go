@@ -0,0 +1,226 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"strconv"
+	"syscall"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+	"github.com/naegelejd/go-acl/os/group"
+	"golang.org/x/sys/unix"
+)
+
+type NodeUnix struct {
+	*emission.Emitter
+	node *action.Node
+
+	phaseMap map[string]string
+}
+
+func NewNodeUnix(node action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &NodeUnix{emitter, node.(*action.Node), phaseMap}
+}
+
+func (n *NodeUnix) Realize(ctx context.Context) error {
+	switch n.phaseMap[Phase(ctx)] {
+	case "install":
+		return n.install(ctx)
+	case "package":
+		n.Emit("action.info", fmt.Sprintf("%s %s", n.node.Type(), n.node.Key()))
+		return n.pkg(ctx)
+	case "remove":
+		return n.remove(ctx)
+	case "verify":
+		return n.verify(ctx)
+	default:
+		return nil
+	}
+}
+
+func (n *NodeUnix) install(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, n.node, n.node.Path)
+	if err != nil {
+		return err
+	}
+
+	mode, err := strconv.ParseUint(n.node.Mode, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	// Unlink rather than rewrite, the journal may hold a hard link to the original
+	os.Remove(target)
+
+	switch n.node.Kind {
+	case action.NodeFifo:
+		err = unix.Mkfifo(target, uint32(mode))
+	case action.NodeChar, action.NodeBlock:
+		// Only a super user can create devices, images owned by other users skip them
+		if os.Geteuid() != 0 {
+			n.Emit("action.warn", fmt.Sprintf("%s %s device requires super user, skipped", n.node.Type(), n.node.Key()))
+			return nil
+		}
+
+		err = mknod(target, n.kindMode()|uint32(mode), unix.Mkdev(uint32(n.node.Major), uint32(n.node.Minor)))
+	default:
+		err = fmt.Errorf("unknown node kind %s", n.node.Kind)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = chownObject(n.Emitter, options.TargetPath, n.node, target, n.node.Owner, n.node.Group)
+	if err != nil {
+		return err
+	}
+
+	os.Chmod(target, os.FileMode(mode))
+
+	return nil
+}
+
+func (n *NodeUnix) pkg(ctx context.Context) error {
+	options := Opts(ctx)
+	target := path.Join(options.TargetPath, n.node.Path)
+
+	info, err := os.Lstat(target)
+	if err != nil {
+		return err
+	}
+
+	stat := info.Sys().(*syscall.Stat_t)
+
+	kind := nodeKind(info)
+	if kind == "" {
+		return fmt.Errorf("%s is not a special file", n.node.Path)
+	}
+
+	n.node.Kind = kind
+
+	if kind != action.NodeFifo {
+		n.node.Major = int(unix.Major(uint64(stat.Rdev)))
+		n.node.Minor = int(unix.Minor(uint64(stat.Rdev)))
+	}
+
+	if n.node.Mode == "" {
+		n.node.Mode = fmt.Sprintf("%#o", info.Mode().Perm())
+	}
+
+	if n.node.Owner == "" {
+		if options.Secure {
+			n.node.Owner = "root"
+		} else if options.Owner != "" {
+			n.node.Owner = options.Owner
+		} else {
+			usr, err := user.LookupId(fmt.Sprint(stat.Uid))
+			if err != nil {
+				return err
+			}
+			n.node.Owner = usr.Username
+		}
+	}
+
+	if n.node.Group == "" {
+		if options.Secure {
+			n.node.Group = "root"
+		} else if options.Group != "" {
+			n.node.Group = options.Group
+		} else {
+			grp, err := group.LookupId(fmt.Sprint(stat.Gid))
+			if err != nil {
+				return err
+			}
+			n.node.Group = grp.Name
+		}
+	}
+
+	return nil
+}
+
+func (n *NodeUnix) verify(ctx context.Context) error {
+	options := Opts(ctx)
+	target := path.Join(options.TargetPath, n.node.Path)
+	drift := &Drift{}
+
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		drift.Add("missing")
+		return drift
+	}
+	if err != nil {
+		return err
+	}
+
+	if kind := nodeKind(info); kind != n.node.Kind {
+		drift.Add(fmt.Sprintf("not a %s", n.node.Kind))
+		return drift
+	}
+
+	if n.node.Kind != action.NodeFifo {
+		rdev := uint64(info.Sys().(*syscall.Stat_t).Rdev)
+
+		if int(unix.Major(rdev)) != n.node.Major || int(unix.Minor(rdev)) != n.node.Minor {
+			drift.Add(fmt.Sprintf("device %d:%d != %d:%d", unix.Major(rdev), unix.Minor(rdev), n.node.Major, n.node.Minor))
+		}
+	}
+
+	verifyMode(drift, info, n.node.Mode)
+	verifyOwnership(drift, info, options.TargetPath, n.node.Owner, n.node.Group)
+
+	return drift.Result()
+}
+
+func (n *NodeUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, n.node, n.node.Path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(target)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (n *NodeUnix) kindMode() uint32 {
+	if n.node.Kind == action.NodeBlock {
+		return unix.S_IFBLK
+	}
+
+	return unix.S_IFCHR
+}
+
+func nodeKind(info os.FileInfo) string {
+	mode := info.Mode()
+
+	switch {
+	case mode&os.ModeNamedPipe != 0:
+		return action.NodeFifo
+	case mode&os.ModeCharDevice != 0:
+		return action.NodeChar
+	case mode&os.ModeDevice != 0:
+		return action.NodeBlock
+	}
+
+	return ""
+}
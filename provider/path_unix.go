@@ -0,0 +1,58 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/fezz-io/zps/action"
+)
+
+// safePath joins an action path to the image root for writing. Paths that escape the root
+// are refused as are symlinked parents, following one could write outside the image
+func safePath(targetPath string, act action.Action, objectPath string) (string, error) {
+	if !action.SafePath(objectPath) {
+		return "", fmt.Errorf("%s %s: path %s escapes the image root", act.Type(), act.Key(), objectPath)
+	}
+
+	var parent string
+
+	for _, part := range strings.Split(path.Dir(path.Clean(objectPath)), "/") {
+		if part == "." {
+			continue
+		}
+
+		parent = path.Join(parent, part)
+
+		info, err := os.Lstat(path.Join(targetPath, parent))
+		if os.IsNotExist(err) {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			return "", fmt.Errorf("%s %s: parent %s is a symlink", act.Type(), act.Key(), parent)
+		}
+	}
+
+	return path.Join(targetPath, objectPath), nil
+}
+
+// SafeTarget resolves the image path of a file system object like the providers do, for
+// callers outside them that modify the image
+func SafeTarget(targetPath string, act action.Action) (string, error) {
+	return safePath(targetPath, act, act.Key())
+}
@@ -0,0 +1,83 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/fezz-io/zps/action"
+)
+
+func TestSafePath(t *testing.T) {
+	image, err := ioutil.TempDir("", "zps-path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(image)
+
+	outside, err := ioutil.TempDir("", "zps-outside")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(outside)
+
+	if err := os.MkdirAll(path.Join(image, "usr/bin"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, path.Join(image, "etc")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("bin", path.Join(image, "usr/sbin")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"usr/bin/tool", path.Join(image, "usr/bin/tool")},
+		{"usr/bin", path.Join(image, "usr/bin")},
+		{"usr/bin/./tool", path.Join(image, "usr/bin/tool")},
+		{"opt/missing/parents/tool", path.Join(image, "opt/missing/parents/tool")},
+		{"etc", path.Join(image, "etc")},
+		{"usr/sbin", path.Join(image, "usr/sbin")},
+		{"etc/passwd", ""},
+		{"usr/sbin/tool", ""},
+		{"../escape", ""},
+		{"usr/../../escape", ""},
+		{"/etc/passwd", ""},
+		{".", ""},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			target, err := safePath(image, &action.File{Path: test.path}, test.path)
+			if test.expected == "" {
+				if err == nil {
+					t.Errorf("expected an error, got %s", target)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if target != test.expected {
+				t.Errorf("got %s, want %s", target, test.expected)
+			}
+		})
+	}
+}
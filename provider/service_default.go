@@ -26,7 +26,7 @@ type ServiceDefault struct {
 }
 
 func NewServiceDefault(service action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
-	return &ServiceSystemD{emitter, service.(*action.Service), phaseMap}
+	return &ServiceDefault{emitter, service.(*action.Service), phaseMap}
 }
 
 func (s *ServiceDefault) Realize(ctx context.Context) error {
@@ -0,0 +1,142 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"fmt"
+	"os"
+)
+
+// ServiceManager is the service manager backend behind Service actions
+type ServiceManager interface {
+	Link(unit string, source string) error
+	UnLink(unit string) error
+	DaemonReload() error
+
+	Enable(unit string) error
+	Disable(unit string) error
+	Start(unit string) error
+	Stop(unit string) error
+	Restart(unit string) error
+	Reload(unit string) error
+}
+
+// MemoryServiceManager records service operations and tracks unit state without
+// touching the host, for tests and images that do not run services
+type MemoryServiceManager struct {
+	Calls []string
+	Units map[string]*MemoryUnit
+}
+
+type MemoryUnit struct {
+	Source  string
+	Enabled bool
+	Active  bool
+}
+
+func NewMemoryServiceManager() *MemoryServiceManager {
+	return &MemoryServiceManager{Units: make(map[string]*MemoryUnit)}
+}
+
+func (m *MemoryServiceManager) Link(unit string, source string) error {
+	m.record("link", unit)
+
+	if existing, ok := m.Units[unit]; ok {
+		existing.Source = source
+		return nil
+	}
+
+	m.Units[unit] = &MemoryUnit{Source: source}
+
+	return nil
+}
+
+func (m *MemoryServiceManager) UnLink(unit string) error {
+	m.record("unlink", unit)
+
+	if _, ok := m.Units[unit]; !ok {
+		return &os.PathError{Op: "remove", Path: unit, Err: os.ErrNotExist}
+	}
+
+	delete(m.Units, unit)
+
+	return nil
+}
+
+func (m *MemoryServiceManager) DaemonReload() error {
+	m.record("daemon-reload", "")
+
+	return nil
+}
+
+func (m *MemoryServiceManager) Enable(unit string) error {
+	return m.update("enable", unit, func(u *MemoryUnit) error {
+		u.Enabled = true
+		return nil
+	})
+}
+
+func (m *MemoryServiceManager) Disable(unit string) error {
+	return m.update("disable", unit, func(u *MemoryUnit) error {
+		u.Enabled = false
+		return nil
+	})
+}
+
+func (m *MemoryServiceManager) Start(unit string) error {
+	return m.update("start", unit, func(u *MemoryUnit) error {
+		u.Active = true
+		return nil
+	})
+}
+
+func (m *MemoryServiceManager) Stop(unit string) error {
+	return m.update("stop", unit, func(u *MemoryUnit) error {
+		u.Active = false
+		return nil
+	})
+}
+
+func (m *MemoryServiceManager) Restart(unit string) error {
+	return m.update("restart", unit, func(u *MemoryUnit) error {
+		u.Active = true
+		return nil
+	})
+}
+
+func (m *MemoryServiceManager) Reload(unit string) error {
+	return m.update("reload", unit, func(u *MemoryUnit) error {
+		if !u.Active {
+			return fmt.Errorf("unit not active: %s", unit)
+		}
+
+		return nil
+	})
+}
+
+func (m *MemoryServiceManager) record(operation string, unit string) {
+	if unit == "" {
+		m.Calls = append(m.Calls, operation)
+	} else {
+		m.Calls = append(m.Calls, operation+" "+unit)
+	}
+}
+
+func (m *MemoryServiceManager) update(operation string, unit string, apply func(*MemoryUnit) error) error {
+	m.record(operation, unit)
+
+	u, ok := m.Units[unit]
+	if !ok {
+		return fmt.Errorf("unit not exist: %s", unit)
+	}
+
+	return apply(u)
+}
@@ -0,0 +1,239 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2018 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+var UnitPath = "usr/lib/systemd/system"
+
+type ServiceUnit struct {
+	*emission.Emitter
+	service *action.Service
+
+	phaseMap map[string]string
+	manager  ServiceManager
+}
+
+// NewServiceUnit returns a Service provider constructor bound to a service manager backend
+func NewServiceUnit(manager ServiceManager) func(action.Action, map[string]string, *emission.Emitter) Provider {
+	return func(service action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+		return &ServiceUnit{emitter, service.(*action.Service), phaseMap, manager}
+	}
+}
+
+func (s *ServiceUnit) Realize(ctx context.Context) error {
+	switch s.phaseMap[Phase(ctx)] {
+	case "configure":
+		return s.configure(ctx)
+	case "install":
+		return s.install(ctx)
+	case "remove":
+		return s.remove(ctx)
+	default:
+		s.Emit("action.info", fmt.Sprintf("%s %s", s.service.Type(), s.service.Key()))
+		return nil
+	}
+}
+
+func (s *ServiceUnit) configure(ctx context.Context) error {
+	err := s.link(ctx)
+	if err != nil {
+		return err
+	}
+
+	if s.service.Enabled() {
+		err = s.manager.Enable(s.service.Unit())
+		if err != nil {
+			return err
+		}
+
+		err = s.manager.Restart(s.service.Unit())
+		if err != nil {
+			return err
+		}
+	}
+
+	s.Emit("action.info", fmt.Sprintf(
+		"%s %s",
+		s.service.Type(),
+		s.service.Key(),
+	))
+
+	return nil
+}
+
+// install runs once the files of a package have landed, new services and services an
+// upgrade enables are enabled and started, upgraded services are restarted or reloaded
+// as their policy asks
+func (s *ServiceUnit) install(ctx context.Context) error {
+	err := s.link(ctx)
+	if err != nil {
+		return err
+	}
+
+	previous, _ := ctx.Value("previous").(*action.Manifest)
+	installed := manifestService(previous, s.service.Name)
+
+	switch {
+	case !s.service.Enabled():
+		// A service the upgrade disables was stopped on remove
+		return nil
+	case installed == nil || !installed.Enabled():
+		err = s.manager.Enable(s.service.Unit())
+		if err != nil {
+			return err
+		}
+
+		s.Emit("action.info", fmt.Sprintf("%s %s started", s.service.Type(), s.service.Key()))
+		return s.manager.Start(s.service.Unit())
+	case s.service.RestartOnUpgrade:
+		s.Emit("action.info", fmt.Sprintf("%s %s restarted", s.service.Type(), s.service.Key()))
+		return s.manager.Restart(s.service.Unit())
+	case s.service.ReloadOnChange && filesChanged(previous, ctx.Value("manifest").(*action.Manifest)):
+		s.Emit("action.info", fmt.Sprintf("%s %s reloaded", s.service.Type(), s.service.Key()))
+		return s.manager.Reload(s.service.Name + ".service")
+	}
+
+	return nil
+}
+
+// remove runs before the files of a package are removed, an upgrade that keeps the
+// service enabled leaves it running for install to restart
+func (s *ServiceUnit) remove(ctx context.Context) error {
+	next, _ := ctx.Value("next").(*action.Manifest)
+	if upgrade := manifestService(next, s.service.Name); upgrade != nil {
+		if !s.service.Enabled() || upgrade.Enabled() {
+			return nil
+		}
+
+		err := s.stop()
+		if err != nil {
+			return err
+		}
+
+		return s.manager.Disable(s.service.Unit())
+	}
+
+	if s.service.Stopped() {
+		err := s.stop()
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.service.Enabled() {
+		err := s.manager.Disable(s.service.Unit())
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.manager.UnLink(s.service.Name + ".service")
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if s.service.Timer {
+		err := s.manager.UnLink(s.service.Name + ".timer")
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	err = s.manager.DaemonReload()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// stop stops the unit, along with the service a timer activates
+func (s *ServiceUnit) stop() error {
+	s.Emit("action.info", fmt.Sprintf("%s %s stopped", s.service.Type(), s.service.Key()))
+
+	err := s.manager.Stop(s.service.Unit())
+	if err != nil {
+		return err
+	}
+
+	if s.service.Timer {
+		return s.manager.Stop(s.service.Name + ".service")
+	}
+
+	return nil
+}
+
+// link makes the units of the image known to the service manager
+func (s *ServiceUnit) link(ctx context.Context) error {
+	options := Opts(ctx)
+
+	source := filepath.Join(options.TargetPath, UnitPath, s.service.Name+".service")
+
+	err := s.manager.Link(s.service.Name+".service", source)
+	if err != nil {
+		return err
+	}
+
+	if s.service.Timer {
+		source := filepath.Join(options.TargetPath, UnitPath, s.service.Name+".timer")
+
+		err := s.manager.Link(s.service.Name+".timer", source)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.manager.DaemonReload()
+}
+
+// manifestService looks up a service in the manifest of another version of the package
+func manifestService(manifest *action.Manifest, name string) *action.Service {
+	if manifest == nil {
+		return nil
+	}
+
+	for _, service := range manifest.Services {
+		if service.Name == name {
+			return service
+		}
+	}
+
+	return nil
+}
+
+// filesChanged reports whether an upgrade changed the content of any packaged file
+func filesChanged(previous *action.Manifest, current *action.Manifest) bool {
+	if previous == nil || current == nil || len(previous.Files) != len(current.Files) {
+		return true
+	}
+
+	for _, file := range current.Files {
+		installed := manifestFile(previous, file.Path)
+		if installed == nil || installed.Digest != file.Digest {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,222 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/chuckpreslar/emission"
+
+	"github.com/fezz-io/zps/action"
+	"github.com/fezz-io/zps/phase"
+)
+
+type serviceStep struct {
+	phase    string
+	previous *action.Manifest
+	next     *action.Manifest
+
+	// Service declared by the version of the package the step runs for, defaults to the test service
+	service *action.Service
+}
+
+func TestServiceUnitOrdering(t *testing.T) {
+	disabled := false
+
+	service := &action.Service{Name: "app", RestartOnUpgrade: true}
+	timer := &action.Service{Name: "job", Timer: true}
+	manual := &action.Service{Name: "app", Enable: &disabled, StopOnRemove: &disabled}
+	disabledRestart := &action.Service{Name: "app", Enable: &disabled, RestartOnUpgrade: true}
+	reloaded := &action.Service{Name: "app", ReloadOnChange: true}
+
+	manifest := func(service *action.Service, digest string) *action.Manifest {
+		return &action.Manifest{
+			Files:    []*action.File{{Path: "etc/app.conf", Digest: digest}},
+			Services: []*action.Service{service},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		service *action.Service
+		steps   []serviceStep
+		calls   []string
+		active  bool
+	}{
+		{
+			name:    "install",
+			service: service,
+			steps:   []serviceStep{{phase: phase.INSTALL}},
+			calls:   []string{"link app.service", "daemon-reload", "enable app.service", "start app.service"},
+			active:  true,
+		},
+		{
+			name:    "install disabled",
+			service: manual,
+			steps:   []serviceStep{{phase: phase.INSTALL}},
+			calls:   []string{"link app.service", "daemon-reload"},
+		},
+		{
+			name:    "install timer",
+			service: timer,
+			steps:   []serviceStep{{phase: phase.INSTALL}},
+			calls:   []string{"link job.service", "link job.timer", "daemon-reload", "enable job.timer", "start job.timer"},
+			active:  true,
+		},
+		{
+			name:    "upgrade restarts",
+			service: service,
+			steps: []serviceStep{
+				{phase: phase.INSTALL},
+				{phase: phase.REMOVE, next: manifest(service, "b")},
+				{phase: phase.INSTALL, previous: manifest(service, "a")},
+			},
+			calls: []string{
+				"link app.service", "daemon-reload", "enable app.service", "start app.service",
+				"link app.service", "daemon-reload", "restart app.service",
+			},
+			active: true,
+		},
+		{
+			name:    "upgrade reloads changed files",
+			service: reloaded,
+			steps: []serviceStep{
+				{phase: phase.INSTALL},
+				{phase: phase.REMOVE, next: manifest(reloaded, "b")},
+				{phase: phase.INSTALL, previous: manifest(reloaded, "a")},
+			},
+			calls: []string{
+				"link app.service", "daemon-reload", "enable app.service", "start app.service",
+				"link app.service", "daemon-reload", "reload app.service",
+			},
+			active: true,
+		},
+		{
+			name:    "upgrade keeps unchanged files",
+			service: reloaded,
+			steps: []serviceStep{
+				{phase: phase.INSTALL},
+				{phase: phase.REMOVE, next: manifest(reloaded, "b")},
+				{phase: phase.INSTALL, previous: manifest(reloaded, "b")},
+			},
+			calls: []string{
+				"link app.service", "daemon-reload", "enable app.service", "start app.service",
+				"link app.service", "daemon-reload",
+			},
+			active: true,
+		},
+		{
+			name:    "upgrade keeps disabled stopped",
+			service: disabledRestart,
+			steps: []serviceStep{
+				{phase: phase.INSTALL},
+				{phase: phase.REMOVE, next: manifest(disabledRestart, "b")},
+				{phase: phase.INSTALL, previous: manifest(disabledRestart, "a")},
+			},
+			calls: []string{"link app.service", "daemon-reload", "link app.service", "daemon-reload"},
+		},
+		{
+			name:    "upgrade enables",
+			service: service,
+			steps: []serviceStep{
+				{phase: phase.INSTALL, service: manual},
+				{phase: phase.REMOVE, next: manifest(service, "b"), service: manual},
+				{phase: phase.INSTALL, previous: manifest(manual, "a")},
+			},
+			calls: []string{
+				"link app.service", "daemon-reload",
+				"link app.service", "daemon-reload", "enable app.service", "start app.service",
+			},
+			active: true,
+		},
+		{
+			name:    "upgrade disables",
+			service: manual,
+			steps: []serviceStep{
+				{phase: phase.INSTALL, service: service},
+				{phase: phase.REMOVE, next: manifest(manual, "b"), service: service},
+				{phase: phase.INSTALL, previous: manifest(service, "a")},
+			},
+			calls: []string{
+				"link app.service", "daemon-reload", "enable app.service", "start app.service",
+				"stop app.service", "disable app.service",
+				"link app.service", "daemon-reload",
+			},
+		},
+		{
+			name:    "remove",
+			service: service,
+			steps:   []serviceStep{{phase: phase.INSTALL}, {phase: phase.REMOVE}},
+			calls: []string{
+				"link app.service", "daemon-reload", "enable app.service", "start app.service",
+				"stop app.service", "disable app.service", "unlink app.service", "daemon-reload",
+			},
+		},
+		{
+			name:    "remove disabled",
+			service: manual,
+			steps:   []serviceStep{{phase: phase.INSTALL}, {phase: phase.REMOVE}},
+			calls:   []string{"link app.service", "daemon-reload", "unlink app.service", "daemon-reload"},
+		},
+		{
+			name:    "remove timer",
+			service: timer,
+			steps:   []serviceStep{{phase: phase.INSTALL}, {phase: phase.REMOVE}},
+			calls: []string{
+				"link job.service", "link job.timer", "daemon-reload", "enable job.timer", "start job.timer",
+				"stop job.timer", "stop job.service", "disable job.timer", "unlink job.service", "unlink job.timer", "daemon-reload",
+			},
+		},
+	}
+
+	phaseMap := map[string]string{phase.INSTALL: "install", phase.REMOVE: "remove"}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := NewMemoryServiceManager()
+
+			for _, step := range test.steps {
+				service := test.service
+				if step.service != nil {
+					service = step.service
+				}
+
+				unit := NewServiceUnit(manager)(service, phaseMap, emission.NewEmitter())
+
+				current := manifest(service, "b")
+				if step.phase == phase.REMOVE {
+					current = manifest(service, "a")
+				}
+
+				ctx := context.WithValue(context.Background(), "phase", step.phase)
+				ctx = context.WithValue(ctx, "options", &Options{TargetPath: "/image"})
+				ctx = context.WithValue(ctx, "manifest", current)
+				ctx = context.WithValue(ctx, "previous", step.previous)
+				ctx = context.WithValue(ctx, "next", step.next)
+
+				err := unit.Realize(ctx)
+				if err != nil {
+					t.Fatalf("%s: %s", step.phase, err)
+				}
+			}
+
+			if !reflect.DeepEqual(manager.Calls, test.calls) {
+				t.Errorf("calls:\n got %v\nwant %v", manager.Calls, test.calls)
+			}
+
+			if u, ok := manager.Units[test.service.Unit()]; ok && u.Active != test.active {
+				t.Errorf("active: got %v, want %v", u.Active, test.active)
+			}
+		})
+	}
+}
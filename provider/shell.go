@@ -0,0 +1,134 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+var HookShell = "/bin/sh"
+
+var HookTimeout = 5 * time.Minute
+
+// Hooks get a fixed PATH rather than inheriting the environment of the zps invocation
+var HookPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// runCommand runs the command of a hook or trigger in the image, failures are reported
+// against the lower cased action type and name
+func runCommand(ctx context.Context, emitter *emission.Emitter, act action.Action, name string, command string, timeout string, env []string) error {
+	options := Opts(ctx)
+	kind := strings.ToLower(act.Type())
+
+	duration := HookTimeout
+	if timeout != "" {
+		var err error
+
+		duration, err = time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("%s %s: invalid timeout %s", kind, name, timeout)
+		}
+	}
+
+	timedOut, err := runShell(emitter, fmt.Sprintf("%s %s", act.Type(), act.Key()), command, options.TargetPath, env, duration)
+	if timedOut {
+		return fmt.Errorf("%s %s timed out after %s", kind, name, duration)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %s", kind, name, err.Error())
+	}
+
+	return nil
+}
+
+// runShell runs a command with the hook shell, output is forwarded line by line as
+// info prefixed by label once the command exits
+func runShell(emitter *emission.Emitter, label string, command string, dir string, env []string, timeout time.Duration) (bool, error) {
+	cmd := exec.Command(HookShell, "-c", command)
+	cmd.Dir = dir
+	cmd.Env = env
+
+	// Commands run in their own process group so a timeout also stops anything they spawned
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Start()
+	if err != nil {
+		return false, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var timedOut bool
+
+	select {
+	case err = <-done:
+	case <-time.After(timeout):
+		timedOut = true
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		err = <-done
+	}
+
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		emitter.Emit("action.info", fmt.Sprintf("%s: %s", label, scanner.Text()))
+	}
+
+	return timedOut, err
+}
+
+// shellEnv is the environment shared by hooks and triggers, callers append their own variables
+func shellEnv(ctx context.Context) []string {
+	options := Opts(ctx)
+
+	env := []string{
+		"PATH=" + shellPath(options.TargetPath),
+		"LANG=C",
+		"ZPS_IMAGE=" + options.TargetPath,
+	}
+
+	if manifest, ok := ctx.Value("manifest").(*action.Manifest); ok && manifest != nil {
+		env = append(env, "ZPS_PACKAGE="+manifest.Zpkg.Name, "ZPS_VERSION="+manifest.Zpkg.Version)
+	}
+
+	return env
+}
+
+// shellPath puts the bin dirs of an image ahead of the host ones, so commands run the tools
+// the image provides and only fall back to the host for what it lacks. Commands are not
+// chrooted, they must still address image files relative to the working directory.
+func shellPath(targetPath string) string {
+	if filepath.Clean(targetPath) == "/" {
+		return HookPath
+	}
+
+	var dirs []string
+	for _, dir := range filepath.SplitList(HookPath) {
+		dirs = append(dirs, filepath.Join(targetPath, dir))
+	}
+
+	return strings.Join(append(dirs, HookPath), string(filepath.ListSeparator))
+}
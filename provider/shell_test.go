@@ -0,0 +1,43 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShellPath(t *testing.T) {
+	tests := []struct {
+		targetPath string
+		first      string
+	}{
+		{"/", "/usr/local/sbin"},
+		{"//", "/usr/local/sbin"},
+		{"/images/app", "/images/app/usr/local/sbin"},
+		{"/images/app/", "/images/app/usr/local/sbin"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.targetPath, func(t *testing.T) {
+			path := shellPath(test.targetPath)
+
+			if !strings.HasPrefix(path, test.first+":") {
+				t.Errorf("got %s, want it to start with %s", path, test.first)
+			}
+
+			// The host dirs always remain as a fallback
+			if !strings.HasSuffix(path, HookPath) {
+				t.Errorf("got %s, want it to end with %s", path, HookPath)
+			}
+		})
+	}
+}
@@ -15,7 +15,6 @@ import (
 	"os"
 	"os/user"
 	"path"
-	"strconv"
 	"syscall"
 
 	"github.com/chuckpreslar/emission"
@@ -46,6 +45,8 @@ func (s *SymLinkUnix) Realize(ctx context.Context) error {
 		return s.pkg(ctx)
 	case "remove":
 		return s.remove(ctx)
+	case "verify":
+		return s.verify(ctx)
 	default:
 		return nil
 	}
@@ -53,28 +54,27 @@ func (s *SymLinkUnix) Realize(ctx context.Context) error {
 
 func (s *SymLinkUnix) install(ctx context.Context) error {
 	options := Opts(ctx)
-	target := path.Join(options.TargetPath, s.symlink.Path)
-
-	err := os.Symlink(s.symlink.Target, target)
-	if err != nil && !os.IsExist(err) {
+	target, err := safePath(options.TargetPath, s.symlink, s.symlink.Path)
+	if err != nil {
 		return err
 	}
 
-	// Silent failures are fine, only a super user can chown to another user
-	// Also a given user may not exist on a system though we should catch
-	// that elsewhere
+	if !action.SafeLinkTarget(s.symlink.Path, s.symlink.Target) {
+		return fmt.Errorf("%s %s: target %s escapes the image root", s.symlink.Type(), s.symlink.Key(), s.symlink.Target)
+	}
 
-	owner, _ := user.Lookup(s.symlink.Owner)
-	grp, _ := user.LookupGroup(s.symlink.Group)
-	var uid int64
-	var gid int64
+	// Replace rather than keep an existing link, it may point elsewhere
+	os.Remove(target)
 
-	if owner != nil && grp != nil {
-		uid, _ = strconv.ParseInt(owner.Uid, 0, 0)
-		gid, _ = strconv.ParseInt(grp.Gid, 0, 0)
+	err = os.Symlink(s.symlink.Target, target)
+	if err != nil && !os.IsExist(err) {
+		return err
 	}
 
-	os.Chown(target, int(uid), int(gid))
+	err = chownObject(s.Emitter, options.TargetPath, s.symlink, target, s.symlink.Owner, s.symlink.Group)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -124,11 +124,46 @@ func (s *SymLinkUnix) pkg(ctx context.Context) error {
 	return err
 }
 
-func (s *SymLinkUnix) remove(ctx context.Context) error {
+// Ownership is not verified, install chowns the link target rather than the link
+func (s *SymLinkUnix) verify(ctx context.Context) error {
 	options := Opts(ctx)
 	target := path.Join(options.TargetPath, s.symlink.Path)
+	drift := &Drift{}
+
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		drift.Add("missing")
+		return drift
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.Mode()&os.ModeSymlink != os.ModeSymlink {
+		drift.Add("not a symlink")
+		return drift
+	}
+
+	link, err := os.Readlink(target)
+	if err != nil {
+		return err
+	}
+
+	if link != s.symlink.Target {
+		drift.Add(fmt.Sprintf("target %s != %s", link, s.symlink.Target))
+	}
+
+	return drift.Result()
+}
+
+func (s *SymLinkUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+	target, err := safePath(options.TargetPath, s.symlink, s.symlink.Path)
+	if err != nil {
+		return err
+	}
 
-	err := os.Remove(target)
+	err = os.Remove(target)
 	if os.IsNotExist(err) {
 		return nil
 	}
@@ -14,9 +14,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/user"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/chuckpreslar/emission"
 	"github.com/hashicorp/hcl/v2"
@@ -51,8 +51,14 @@ func (t *TemplateDefault) Realize(ctx context.Context) error {
 func (t *TemplateDefault) configure(ctx context.Context) error {
 	options := Opts(ctx)
 
+	// Template paths may be written as absolute paths within the image
+	source, err := safePath(options.TargetPath, t.template, strings.TrimPrefix(t.template.Source, "/"))
+	if err != nil {
+		return err
+	}
+
 	// Process template
-	configBytes, err := ioutil.ReadFile(filepath.Join(options.TargetPath, t.template.Source))
+	configBytes, err := ioutil.ReadFile(source)
 	if err != nil {
 		return err
 	}
@@ -69,7 +75,15 @@ func (t *TemplateDefault) configure(ctx context.Context) error {
 	}
 
 	if t.template.Output != "" {
-		output := filepath.Join(options.TargetPath, t.template.Output)
+		output, err := safePath(options.TargetPath, t.template, strings.TrimPrefix(t.template.Output, "/"))
+		if err != nil {
+			return err
+		}
+
+		// Writing would follow a symlink in place of the output
+		if info, err := os.Lstat(output); err == nil && info.Mode()&os.ModeSymlink == os.ModeSymlink {
+			return fmt.Errorf("%s %s: output %s is a symlink", t.template.Type(), t.template.Key(), t.template.Output)
+		}
 
 		modeString := t.template.Mode
 		if modeString == "" {
@@ -85,22 +99,11 @@ func (t *TemplateDefault) configure(ctx context.Context) error {
 		if err != nil {
 			return err
 		}
-		// Silent failures are fine, only a super user can chown to another user
-		// Also a given user may not exist on a system though we should catch
-		// that elsewhere
-
-		owner, _ := user.Lookup(t.template.Owner)
-		grp, _ := user.LookupGroup(t.template.Group)
-		var uid int64
-		var gid int64
-
-		if owner != nil && grp != nil {
-			uid, _ = strconv.ParseInt(owner.Uid, 0, 0)
-			gid, _ = strconv.ParseInt(grp.Gid, 0, 0)
+		err = chownObject(t.Emitter, options.TargetPath, t.template, output, t.template.Owner, t.template.Group)
+		if err != nil {
+			return err
 		}
 
-		os.Chown(output, int(uid), int(gid))
-
 		t.Emit("action.info", fmt.Sprintf(
 			"%s %s %s => %s",
 			t.template.Type(),
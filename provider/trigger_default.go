@@ -0,0 +1,62 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+type TriggerDefault struct {
+	*emission.Emitter
+	trigger *action.Trigger
+
+	phaseMap map[string]string
+}
+
+func NewTriggerDefault(trigger action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &TriggerDefault{emitter, trigger.(*action.Trigger), phaseMap}
+}
+
+func (t *TriggerDefault) Realize(ctx context.Context) error {
+	switch t.phaseMap[Phase(ctx)] {
+	case "run":
+		return t.run(ctx)
+	default:
+		t.Emit("action.info", fmt.Sprintf("%s %s", t.trigger.Type(), t.trigger.Key()))
+		return nil
+	}
+}
+
+func (t *TriggerDefault) run(ctx context.Context) error {
+	t.Emit("action.info", fmt.Sprintf("%s %s", t.trigger.Type(), t.trigger.Key()))
+
+	return runCommand(ctx, t.Emitter, t.trigger, t.trigger.Name, t.trigger.Command, t.trigger.Timeout, t.env(ctx))
+}
+
+// env lists the touched paths that activated the trigger, trigger defined variables are applied last
+func (t *TriggerDefault) env(ctx context.Context) []string {
+	env := append(shellEnv(ctx), "ZPS_TRIGGER="+t.trigger.Name)
+
+	if touched, ok := ctx.Value("triggered").([]string); ok {
+		env = append(env, "ZPS_TRIGGER_PATHS="+strings.Join(touched, "\n"))
+	}
+
+	for key, value := range t.trigger.Env {
+		env = append(env, key+"="+value)
+	}
+
+	return env
+}
@@ -0,0 +1,152 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+var (
+	SystemHome  = "/nonexistent"
+	SystemShell = "/usr/sbin/nologin"
+	HomePath    = "/home"
+	Shell       = "/bin/sh"
+)
+
+type UserUnix struct {
+	*emission.Emitter
+	user *action.User
+
+	phaseMap map[string]string
+}
+
+func NewUserUnix(user action.Action, phaseMap map[string]string, emitter *emission.Emitter) Provider {
+	return &UserUnix{emitter, user.(*action.User), phaseMap}
+}
+
+func (u *UserUnix) Realize(ctx context.Context) error {
+	switch u.phaseMap[Phase(ctx)] {
+	case "install":
+		return u.install(ctx)
+	case "remove":
+		return u.remove(ctx)
+	default:
+		u.Emit("action.info", fmt.Sprintf("%s %s", u.user.Type(), u.user.Key()))
+		return nil
+	}
+}
+
+// Existing users are left as they are, they may have been created by an administrator
+func (u *UserUnix) install(ctx context.Context) error {
+	options := Opts(ctx)
+
+	passwd, err := readAccountDb(options.TargetPath, PasswdPath)
+	if err != nil {
+		return err
+	}
+
+	if passwd.Get(u.user.Name) != nil {
+		return nil
+	}
+
+	groups, err := readAccountDb(options.TargetPath, GroupPath)
+	if err != nil {
+		return err
+	}
+
+	gid, ok := groups.Id(u.user.PrimaryGroup())
+	if !ok {
+		return fmt.Errorf("primary group %s of user %s does not exist", u.user.PrimaryGroup(), u.user.Name)
+	}
+
+	// Prefer a uid matching the primary gid, as useradd does for user private groups
+	uid := u.user.Uid
+	if uid == 0 {
+		uid, err = passwd.Allocate(u.user.System, gid)
+		if err != nil {
+			return err
+		}
+	} else if passwd.IdUsed(uid) {
+		return fmt.Errorf("uid %d for user %s is already in use", uid, u.user.Name)
+	}
+
+	home, shell := u.user.Home, u.user.Shell
+	if home == "" {
+		home = path.Join(HomePath, u.user.Name)
+
+		if u.user.System {
+			home = SystemHome
+		}
+	}
+
+	if shell == "" {
+		shell = Shell
+
+		if u.user.System {
+			shell = SystemShell
+		}
+	}
+
+	passwd.Add(u.user.Name, "x", strconv.Itoa(uid), strconv.Itoa(gid), "", home, shell)
+
+	err = passwd.Write()
+	if err != nil {
+		return err
+	}
+
+	shadow, err := readAccountDb(options.TargetPath, ShadowPath)
+	if err != nil {
+		return err
+	}
+
+	// Accounts are created locked, a password is an administrative decision
+	if shadow.exists && shadow.Get(u.user.Name) == nil {
+		shadow.Add(u.user.Name, "!", "", "", "", "", "", "", "")
+
+		err = shadow.Write()
+		if err != nil {
+			return err
+		}
+	}
+
+	u.Emit("action.info", fmt.Sprintf("%s %s uid %d gid %d", u.user.Type(), u.user.Key(), uid, gid))
+
+	return nil
+}
+
+// Transactions only remove accounts zps created, existing ones are never passed here
+func (u *UserUnix) remove(ctx context.Context) error {
+	options := Opts(ctx)
+
+	for _, dbPath := range []string{ShadowPath, PasswdPath} {
+		db, err := readAccountDb(options.TargetPath, dbPath)
+		if err != nil {
+			return err
+		}
+
+		if db.Del(u.user.Name) {
+			err = db.Write()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	u.Emit("action.info", fmt.Sprintf("%s %s removed", u.user.Type(), u.user.Key()))
+
+	return nil
+}
@@ -0,0 +1,99 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Drift is returned from the verify phase when an installed object no longer matches its action
+type Drift struct {
+	Reasons []string
+}
+
+func (d *Drift) Error() string {
+	return strings.Join(d.Reasons, ", ")
+}
+
+func (d *Drift) Add(reason string) {
+	d.Reasons = append(d.Reasons, reason)
+}
+
+func (d *Drift) Result() error {
+	if len(d.Reasons) == 0 {
+		return nil
+	}
+
+	return d
+}
+
+func IsDrift(err error) bool {
+	var drift *Drift
+
+	return errors.As(err, &drift)
+}
+
+func verifyMode(drift *Drift, info os.FileInfo, modeString string) {
+	mode, err := strconv.ParseUint(modeString, 0, 0)
+	if err != nil {
+		drift.Add(fmt.Sprint("invalid manifest mode ", modeString))
+		return
+	}
+
+	if info.Mode().Perm() != os.FileMode(mode).Perm() {
+		drift.Add(fmt.Sprintf("mode %#o != %#o", info.Mode().Perm(), os.FileMode(mode).Perm()))
+	}
+}
+
+// Ownership can only have been applied by a super user, so it is only verified as one
+func verifyOwnership(drift *Drift, info os.FileInfo, targetPath string, owner string, group string) {
+	if os.Geteuid() != 0 {
+		return
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+
+	if uid, err := lookupId(targetPath, PasswdPath, owner); err == nil && uint32(uid) != stat.Uid {
+		drift.Add(fmt.Sprintf("owner %d != %s", stat.Uid, owner))
+	}
+
+	if gid, err := lookupId(targetPath, GroupPath, group); err == nil && uint32(gid) != stat.Gid {
+		drift.Add(fmt.Sprintf("group %d != %s", stat.Gid, group))
+	}
+}
+
+func fileDigest(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+
+	_, err = io.Copy(hasher, file)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,85 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// Only user attributes and file capabilities are portable between images, trusted and
+// system attributes are host specific and selinux labels are applied by policy
+func packagedXattr(name string) bool {
+	return strings.HasPrefix(name, "user.") || name == "security.capability"
+}
+
+// readXattrs returns the packaged attributes of a file with base64 encoded values
+func readXattrs(target string) (map[string]string, error) {
+	size, err := unix.Llistxattr(target, nil)
+	if err == unix.ENOTSUP || size == 0 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]byte, size)
+	size, err = unix.Llistxattr(target, list)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string]string)
+
+	for _, name := range bytes.Split(list[:size], []byte{0}) {
+		if len(name) == 0 || !packagedXattr(string(name)) {
+			continue
+		}
+
+		vsize, err := unix.Lgetxattr(target, string(name), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		value := make([]byte, vsize)
+		vsize, err = unix.Lgetxattr(target, string(name), value)
+		if err != nil {
+			return nil, err
+		}
+
+		xattrs[string(name)] = base64.StdEncoding.EncodeToString(value[:vsize])
+	}
+
+	if len(xattrs) == 0 {
+		return nil, nil
+	}
+
+	return xattrs, nil
+}
+
+func writeXattrs(target string, xattrs map[string]string) error {
+	for name, encoded := range xattrs {
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return err
+		}
+
+		err = unix.Lsetxattr(target, name, value, 0)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+// +build !linux
+
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package provider
+
+import "errors"
+
+func readXattrs(target string) (map[string]string, error) {
+	return nil, nil
+}
+
+func writeXattrs(target string, xattrs map[string]string) error {
+	if len(xattrs) == 0 {
+		return nil
+	}
+
+	return errors.New("extended attributes are not supported on this platform")
+}
@@ -0,0 +1,88 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+var SystemctlPath = "/usr/bin/systemctl"
+
+// Systemctl manages units through the system manager or, with User set, the service
+// manager of the calling user
+type Systemctl struct {
+	User bool
+}
+
+// UnitPath is where units are linked, the user path follows XDG_CONFIG_HOME
+func (s *Systemctl) UnitPath() string {
+	if !s.User {
+		return "/etc/systemd/system"
+	}
+
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		home, _ := os.UserHomeDir()
+		config = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(config, "systemd", "user")
+}
+
+func (s *Systemctl) Link(unit string, source string) error {
+	err := os.MkdirAll(s.UnitPath(), 0755)
+	if err != nil {
+		return err
+	}
+
+	os.Remove(filepath.Join(s.UnitPath(), unit))
+
+	return os.Symlink(source, filepath.Join(s.UnitPath(), unit))
+}
+
+func (s *Systemctl) UnLink(unit string) error {
+	return os.Remove(filepath.Join(s.UnitPath(), unit))
+}
+
+func (s *Systemctl) DaemonReload() error {
+	return s.run("daemon-reload")
+}
+
+func (s *Systemctl) Enable(unit string) error {
+	return s.run("enable", unit)
+}
+
+func (s *Systemctl) Disable(unit string) error {
+	return s.run("disable", unit)
+}
+
+func (s *Systemctl) Start(unit string) error {
+	return s.run("start", unit)
+}
+
+func (s *Systemctl) Stop(unit string) error {
+	return s.run("stop", unit)
+}
+
+func (s *Systemctl) Restart(unit string) error {
+	return s.run("restart", unit)
+}
+
+func (s *Systemctl) Reload(unit string) error {
+	return s.run("reload", unit)
+}
+
+func (s *Systemctl) run(args ...string) error {
+	if s.User {
+		args = append([]string{"--user"}, args...)
+	}
+
+	output, err := exec.Command(SystemctlPath, args...).CombinedOutput()
+
+	if err != nil {
+		return fmt.Errorf("%s %s", output, err)
+	}
+
+	return nil
+}
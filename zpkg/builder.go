@@ -15,6 +15,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/fezz-io/zps/phase"
@@ -40,6 +41,12 @@ type Builder struct {
 
 	version uint8
 
+	// Set for reproducible builds, replaces the build time in the version
+	sourceDate time.Time
+
+	// Generate shared library requirements from ELF objects in the payload
+	elfRequirements bool
+
 	manifest *action.Manifest
 
 	filename string
@@ -97,6 +104,32 @@ func (b *Builder) OutputPath(op string) *Builder {
 	return b
 }
 
+// Compression selects the payload compression id and level, a level of 0 selects the default
+func (b *Builder) Compression(compression uint8, level int) *Builder {
+	b.header.Compression = compression
+	b.payload.Compression = compression
+	b.payload.Level = level
+	return b
+}
+
+// ElfRequirements enables generation of provides and depends requirements from ELF sonames
+func (b *Builder) ElfRequirements(enabled bool) *Builder {
+	b.elfRequirements = enabled
+	return b
+}
+
+// Acls enables capture of POSIX ACLs on files and directories without a declared acl
+func (b *Builder) Acls(enabled bool) *Builder {
+	b.options.Acls = enabled
+	return b
+}
+
+// SourceDate enables a reproducible build stamped with date rather than the build time
+func (b *Builder) SourceDate(date time.Time) *Builder {
+	b.sourceDate = date.UTC()
+	return b
+}
+
 func (b *Builder) Version(version uint8) *Builder {
 	b.version = version
 	b.header.Version = version
@@ -158,6 +191,10 @@ func (b *Builder) loadZpkgfile() error {
 
 // Process options deal with any special cases here
 func (b *Builder) processOptions() error {
+	// Ownership is normalized for reproducible builds, it would otherwise depend on the build host
+	if !b.sourceDate.IsZero() {
+		b.options.Secure = true
+	}
 
 	return nil
 }
@@ -171,6 +208,9 @@ func (b *Builder) resolve() error {
 		return nil
 	}
 
+	// First path seen for each linked inode, later paths become hard links to it
+	inodes := make(map[[2]uint64]string)
+
 	err := filepath.Walk(b.options.TargetPath, func(path string, f os.FileInfo, err error) error {
 		objectPath := strings.Replace(path, b.options.TargetPath+string(os.PathSeparator), "", 1)
 
@@ -188,8 +228,43 @@ func (b *Builder) resolve() error {
 				var file = action.NewFile()
 				file.Path = objectPath
 
-				if !b.manifest.Exists(file) {
-					b.manifest.Add(file)
+				var hardlink = action.NewHardLink()
+				hardlink.Path = objectPath
+
+				stat := f.Sys().(*syscall.Stat_t)
+				inode := [2]uint64{uint64(stat.Dev), uint64(stat.Ino)}
+				target, linked := inodes[inode]
+
+				if b.manifest.Exists(file) {
+					if !linked {
+						inodes[inode] = objectPath
+					}
+				} else if !b.manifest.Exists(hardlink) {
+					if linked && stat.Nlink > 1 {
+						hardlink.Target = target
+						b.manifest.Add(hardlink)
+					} else {
+						inodes[inode] = objectPath
+						b.manifest.Add(file)
+					}
+				}
+			}
+
+			if f.Mode()&(os.ModeNamedPipe|os.ModeDevice) != 0 {
+				var node = action.NewNode()
+				node.Path = objectPath
+
+				switch {
+				case f.Mode()&os.ModeNamedPipe != 0:
+					node.Kind = action.NodeFifo
+				case f.Mode()&os.ModeCharDevice != 0:
+					node.Kind = action.NodeChar
+				default:
+					node.Kind = action.NodeBlock
+				}
+
+				if !b.manifest.Exists(node) {
+					b.manifest.Add(node)
 				}
 			}
 
@@ -206,11 +281,7 @@ func (b *Builder) resolve() error {
 		return nil
 	})
 
-	if err != nil {
-		return err
-	}
-
-	return b.manifest.Validate()
+	return err
 }
 
 // Set file name and zpkg timestamp
@@ -220,7 +291,13 @@ func (b *Builder) set() error {
 		return err
 	}
 
-	pkg.Version().Timestamp = time.Now().UTC()
+	if b.sourceDate.IsZero() {
+		pkg.Version().Timestamp = time.Now().UTC()
+	} else {
+		pkg.Version().Timestamp = b.sourceDate
+		b.manifest.Sort()
+	}
+
 	b.manifest.Zpkg.Version = pkg.Version().String()
 
 	b.filename = pkg.FileName()
@@ -270,6 +347,19 @@ func (b *Builder) Build() (string, *action.Manifest, error) {
 		return "", nil, err
 	}
 
+	if b.elfRequirements {
+		err = b.scanElf()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	// Restricted builds skip resolve, declared actions are validated all the same
+	err = b.manifest.Validate()
+	if err != nil {
+		return "", nil, err
+	}
+
 	err = b.set()
 	if err != nil {
 		return "", nil, err
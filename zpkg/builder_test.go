@@ -0,0 +1,156 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fezz-io/zps/zpkg/payload"
+	"github.com/fezz-io/zps/zps"
+)
+
+const testZpkgfile = `
+Zpkg "repro" {
+  publisher = "test"
+  version = "1.0.0"
+  summary = "Reproducible test package"
+  description = "Built twice from the same inputs."
+  os = "linux"
+  arch = "x86_64"
+}
+`
+
+// Declared in a different order by each build
+var testZpkgfileBlocks = []string{
+	"Requirement \"liba\" {\n  method = \"depends\"\n  operation = \"ANY\"\n}\n",
+	"Requirement \"libb\" {\n  method = \"depends\"\n  operation = \"ANY\"\n}\n",
+	"Tag \"repro.a\" {\n  value = \"a\"\n}\n",
+	"Tag \"repro.b\" {\n  value = \"b\"\n}\n",
+	"File \"etc/repro/repro.cfg\" {\n  mode = \"0640\"\n  config = true\n}\n",
+	"File \"usr/bin/repro\" {\n  mode = \"0755\"\n}\n",
+}
+
+func TestBuilderReproducible(t *testing.T) {
+	root, err := ioutil.TempDir("", "zpkg-build")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	files := map[string]string{
+		"usr/bin/repro":       "#!/bin/sh\necho repro\n",
+		"usr/share/repro/a":   "a",
+		"usr/share/repro/b":   "b",
+		"etc/repro/repro.cfg": "key=value\n",
+	}
+
+	// The trees are created in opposite orders with different modification times
+	order := [][]string{
+		{"usr/bin/repro", "usr/share/repro/a", "usr/share/repro/b", "etc/repro/repro.cfg"},
+		{"etc/repro/repro.cfg", "usr/share/repro/b", "usr/share/repro/a", "usr/bin/repro"},
+	}
+
+	sourceDate := time.Unix(1600000000, 0)
+
+	for _, compression := range []string{"bzip2", "zstd"} {
+		t.Run(compression, func(t *testing.T) {
+			compressionId, err := payload.CompressionId(compression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var builds [][]byte
+
+			for index, paths := range order {
+				dir := filepath.Join(root, compression, string(rune('a'+index)))
+
+				for offset, name := range paths {
+					target := filepath.Join(dir, "proto", name)
+
+					err = os.MkdirAll(filepath.Dir(target), 0755)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					err = ioutil.WriteFile(target, []byte(files[name]), 0644)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					mtime := time.Now().Add(time.Duration(offset*index) * time.Hour)
+					os.Chtimes(target, mtime, mtime)
+				}
+
+				zpkgfile := testZpkgfile
+				for block := range testZpkgfileBlocks {
+					if index == 1 {
+						block = len(testZpkgfileBlocks) - 1 - block
+					}
+
+					zpkgfile += testZpkgfileBlocks[block]
+				}
+
+				err = ioutil.WriteFile(filepath.Join(dir, DefaultZpfPath), []byte(zpkgfile), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Packages are written to the working directory
+				err = os.Chdir(dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				filename, manifest, err := NewBuilder().
+					ZpfPath(filepath.Join(dir, DefaultZpfPath)).
+					TargetPath(filepath.Join(dir, "proto")).
+					WorkPath(dir).
+					Compression(compressionId, 0).
+					SourceDate(sourceDate).
+					Build()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				pkg, err := zps.NewPkgFromManifest(manifest)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !pkg.Version().Timestamp.Equal(sourceDate) {
+					t.Errorf("timestamp: got %s, want %s", pkg.Version().Timestamp, sourceDate)
+				}
+
+				content, err := ioutil.ReadFile(filepath.Join(dir, filename))
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				builds = append(builds, content)
+			}
+
+			if !bytes.Equal(builds[0], builds[1]) {
+				t.Errorf("builds differ: %d and %d bytes", len(builds[0]), len(builds[1]))
+			}
+		})
+	}
+}
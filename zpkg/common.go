@@ -12,7 +12,7 @@ package zpkg
 
 /*
 	Versions: 0
-	Compression: 0 bzip2
+	Compression: 0 bzip2, 1 zstd
 */
 
 const (
@@ -0,0 +1,113 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/fezz-io/zps/action"
+)
+
+// ElfLibraries lists the shared library sonames an ELF object provides and needs,
+// ok is false if the file is not an ELF object
+func ElfLibraries(filePath string) (sonames []string, needed []string, ok bool, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(elf.ELFMAG))
+	if _, err := io.ReadFull(file, magic); err != nil || !bytes.Equal(magic, []byte(elf.ELFMAG)) {
+		return nil, nil, false, nil
+	}
+
+	object, err := elf.NewFile(file)
+	if err != nil {
+		return nil, nil, true, fmt.Errorf("invalid ELF object %s: %s", filePath, err.Error())
+	}
+	defer object.Close()
+
+	// Static objects have no dynamic section
+	if object.Section(".dynamic") == nil {
+		return nil, nil, true, nil
+	}
+
+	sonames, err = object.DynString(elf.DT_SONAME)
+	if err != nil {
+		return nil, nil, true, err
+	}
+
+	needed, err = object.DynString(elf.DT_NEEDED)
+	if err != nil {
+		return nil, nil, true, err
+	}
+
+	return sonames, needed, true, nil
+}
+
+// scanElf adds a provides requirement for every soname in the payload and a depends
+// requirement for every needed library the package does not provide itself,
+// requirements declared in the Zpkgfile take precedence
+func (b *Builder) scanElf() error {
+	provided := make(map[string]bool)
+	needed := make(map[string]bool)
+
+	for _, file := range b.manifest.Section("File") {
+		sonames, libraries, ok, err := ElfLibraries(path.Join(b.options.TargetPath, file.Key()))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		for _, soname := range sonames {
+			provided[soname] = true
+		}
+
+		for _, library := range libraries {
+			needed[library] = true
+		}
+	}
+
+	var reqs action.Actions
+
+	for soname := range provided {
+		reqs = append(reqs, &action.Requirement{Name: soname, Method: "provides"})
+	}
+
+	for library := range needed {
+		if !provided[library] {
+			reqs = append(reqs, &action.Requirement{Name: library, Method: "depends", Operation: "ANY"})
+		}
+	}
+
+	sort.Sort(reqs)
+
+	for _, req := range reqs {
+		if b.manifest.Exists(req) {
+			continue
+		}
+
+		b.manifest.Add(req)
+		b.Emit("action.info", fmt.Sprintf("%s %s %s", req.Type(), req.(*action.Requirement).Method, req.Key()))
+	}
+
+	return nil
+}
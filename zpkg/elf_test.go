@@ -0,0 +1,189 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fezz-io/zps/action"
+)
+
+func TestElfLibraries(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		sonames []string
+		needed  []string
+		ok      bool
+	}{
+		{"script", []byte("#!/bin/sh\n"), nil, nil, false},
+		{"static", testElf(false, ""), nil, nil, true},
+		{"executable", testElf(true, "", "libfoo.so.1", "libc.so.6"), nil, []string{"libfoo.so.1", "libc.so.6"}, true},
+		{"library", testElf(true, "libfoo.so.1", "libc.so.6"), []string{"libfoo.so.1"}, []string{"libc.so.6"}, true},
+	}
+
+	root, err := ioutil.TempDir("", "zpkg-elf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := filepath.Join(root, test.name)
+
+			err := ioutil.WriteFile(target, test.content, 0755)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sonames, needed, ok, err := ElfLibraries(target)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if ok != test.ok || !reflect.DeepEqual(sonames, test.sonames) || !reflect.DeepEqual(needed, test.needed) {
+				t.Errorf("got %v %v %v, want %v %v %v", sonames, needed, ok, test.sonames, test.needed, test.ok)
+			}
+		})
+	}
+}
+
+func TestBuilderScanElf(t *testing.T) {
+	root, err := ioutil.TempDir("", "zpkg-elf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	files := map[string][]byte{
+		"usr/lib/libfoo.so.1": testElf(true, "libfoo.so.1", "libbar.so.2", "libc.so.6"),
+		"usr/bin/foo":         testElf(true, "", "libfoo.so.1", "libc.so.6"),
+		"usr/bin/foo-wrapper": []byte("#!/bin/sh\nexec foo\n"),
+	}
+
+	builder := NewBuilder()
+	builder.options.TargetPath = root
+
+	for name, content := range files {
+		err = os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = ioutil.WriteFile(filepath.Join(root, name), content, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		builder.manifest.Add(&action.File{Path: name})
+	}
+
+	// Declared requirements are kept as is
+	builder.manifest.Add(&action.Requirement{Name: "libbar.so.2", Method: "depends", Operation: "GTE", Version: "2.1.0"})
+
+	err = builder.scanElf()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []action.Requirement{
+		{Name: "libbar.so.2", Method: "depends", Operation: "GTE", Version: "2.1.0"},
+		{Name: "libc.so.6", Method: "depends", Operation: "ANY"},
+		{Name: "libfoo.so.1", Method: "provides"},
+	}
+
+	var requirements []action.Requirement
+	for _, req := range builder.manifest.Requirements {
+		requirements = append(requirements, *req)
+	}
+
+	if !reflect.DeepEqual(requirements, expected) {
+		t.Errorf("got %+v, want %+v", requirements, expected)
+	}
+}
+
+// testElf writes a minimal ELF64 object, the dynamic section holds a soname and needed libraries
+func testElf(dynamic bool, soname string, needed ...string) []byte {
+	var dynstr, dyn, shstrtab bytes.Buffer
+
+	dynstr.WriteByte(0)
+
+	addDyn := func(tag elf.DynTag, value string) {
+		binary.Write(&dyn, binary.LittleEndian, elf.Dyn64{Tag: int64(tag), Val: uint64(dynstr.Len())})
+		dynstr.WriteString(value + "\x00")
+	}
+
+	if soname != "" {
+		addDyn(elf.DT_SONAME, soname)
+	}
+
+	for _, library := range needed {
+		addDyn(elf.DT_NEEDED, library)
+	}
+
+	binary.Write(&dyn, binary.LittleEndian, elf.Dyn64{Tag: int64(elf.DT_NULL)})
+
+	shstrtab.WriteString("\x00.shstrtab\x00.dynstr\x00.dynamic\x00")
+
+	headerSize := binary.Size(elf.Header64{})
+	sectionSize := binary.Size(elf.Section64{})
+
+	// Section contents follow the header, section headers come last
+	sections := []elf.Section64{{}, {Name: 1, Type: uint32(elf.SHT_STRTAB), Size: uint64(shstrtab.Len()), Addralign: 1}}
+	contents := [][]byte{shstrtab.Bytes()}
+
+	if dynamic {
+		sections = append(sections,
+			elf.Section64{Name: 11, Type: uint32(elf.SHT_STRTAB), Size: uint64(dynstr.Len()), Addralign: 1},
+			elf.Section64{Name: 19, Type: uint32(elf.SHT_DYNAMIC), Size: uint64(dyn.Len()), Link: 2, Addralign: 8, Entsize: uint64(binary.Size(elf.Dyn64{}))},
+		)
+		contents = append(contents, dynstr.Bytes(), dyn.Bytes())
+	}
+
+	offset := headerSize
+	for index, content := range contents {
+		sections[index+1].Off = uint64(offset)
+		offset += len(content)
+	}
+
+	header := elf.Header64{
+		Type:      uint16(elf.ET_DYN),
+		Machine:   uint16(elf.EM_X86_64),
+		Version:   uint32(elf.EV_CURRENT),
+		Shoff:     uint64(offset),
+		Ehsize:    uint16(headerSize),
+		Shentsize: uint16(sectionSize),
+		Shnum:     uint16(len(sections)),
+		Shstrndx:  1,
+	}
+	copy(header.Ident[:], elf.ELFMAG)
+	header.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	header.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	header.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var object bytes.Buffer
+
+	binary.Write(&object, binary.LittleEndian, header)
+	for _, content := range contents {
+		object.Write(content)
+	}
+	binary.Write(&object, binary.LittleEndian, sections)
+
+	return object.Bytes()
+}
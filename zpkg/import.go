@@ -0,0 +1,513 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fezz-io/zps/action"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/klauspost/compress/zstd"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Import is a foreign package unpacked into a proto tree, its metadata is mapped onto
+// actions and written out as a Zpkgfile for the normal builder
+type Import struct {
+	Manifest *action.Manifest
+
+	// Build time of the source package if known, used as the source date
+	BuildTime time.Time
+
+	// Content with no zps equivalent, such as maintainer scripts
+	Unsupported []string
+
+	// Metadata that could only be mapped approximately
+	Warnings []string
+
+	// rpm file attributes by path, rpm payloads only carry numeric ids
+	rpmFiles map[string]rpmFile
+}
+
+var importVersionRe = regexp.MustCompile(`^(\d+)(?:\.(\d+))?(?:\.(\d+))?(.*)$`)
+
+func NewImport() *Import {
+	manifest := action.NewManifest()
+	manifest.Zpkg = action.NewZpkg()
+
+	return &Import{Manifest: manifest}
+}
+
+// ImportArchive unpacks a .deb, .rpm or tarball into protoPath
+func ImportArchive(archivePath string, protoPath string) (*Import, error) {
+	name := filepath.Base(archivePath)
+
+	err := os.MkdirAll(protoPath, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(name, ".deb"):
+		return importDeb(archivePath, protoPath)
+	case strings.HasSuffix(name, ".rpm"):
+		return importRpm(archivePath, protoPath)
+	case tarballName(name) != name:
+		return importTar(archivePath, protoPath)
+	}
+
+	return nil, fmt.Errorf("unsupported archive format: %s", name)
+}
+
+// ImportVersion maps a foreign upstream version onto semver, exact is false if anything was dropped
+func ImportVersion(version string) (string, bool, error) {
+	exact := true
+
+	// Epochs have no zps equivalent
+	if index := strings.Index(version, ":"); index != -1 {
+		version = version[index+1:]
+		exact = false
+	}
+
+	match := importVersionRe.FindStringSubmatch(version)
+	if match == nil {
+		return "", false, fmt.Errorf("unable to map version %s", version)
+	}
+
+	parts := []string{match[1], match[2], match[3]}
+	for index := range parts {
+		if parts[index] == "" {
+			parts[index] = "0"
+		}
+	}
+
+	if match[4] != "" {
+		exact = false
+	}
+
+	return strings.Join(parts, "."), exact, nil
+}
+
+// ImportArch maps deb and rpm architectures onto zps architectures, architecture
+// independent packages are built for the host architecture
+func ImportArch(arch string) (string, error) {
+	switch arch {
+	case "amd64", "x86_64":
+		return "x86_64", nil
+	case "arm64", "aarch64":
+		return "arm64", nil
+	case "all", "noarch", "":
+		if runtime.GOARCH == "amd64" {
+			return "x86_64", nil
+		}
+
+		return runtime.GOARCH, nil
+	}
+
+	return "", fmt.Errorf("unsupported architecture: %s", arch)
+}
+
+func (i *Import) Warn(format string, args ...interface{}) {
+	i.Warnings = append(i.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Requirement adds a requirement, constraints on the same name and method are combined into a range
+func (i *Import) Requirement(method string, name string, operator string, version string) {
+	req := &action.Requirement{Name: name, Method: method}
+
+	if operator != "" && version != "" {
+		mapped, exact, err := ImportVersion(version)
+		if err != nil {
+			i.Warn("%s %s %s %s imported without version constraint", method, name, operator, version)
+		} else {
+			if !exact {
+				i.Warn("%s %s version %s imported as %s", method, name, version, mapped)
+			}
+
+			req.Version = operator + mapped
+		}
+	}
+
+	if method == "provides" && req.Version != "" {
+		req.Operation = "EQ"
+		req.Version = strings.TrimPrefix(req.Version, "==")
+	}
+
+	if req.Version == "" && method != "provides" {
+		req.Operation = "ANY"
+	}
+
+	for _, existing := range i.Manifest.Requirements {
+		if existing.Name != name || existing.Method != method {
+			continue
+		}
+
+		switch {
+		case req.Version == "":
+			// An unversioned duplicate adds nothing
+		case existing.Version == "":
+			existing.Operation = req.Operation
+			existing.Version = req.Version
+		case method != "provides":
+			existing.Version = existing.Version + ", " + req.Version
+		}
+
+		return
+	}
+
+	// Manifest.Add indexes requirements by name, a package may provide and conflict with the same name
+	i.Manifest.Requirements = append(i.Manifest.Requirements, req)
+}
+
+// Owner records a non root owned fs object, everything else is built super user owned
+func (i *Import) Owner(objPath string, isDir bool, owner string, group string) {
+	if (owner == "" || owner == "root") && (group == "" || group == "root") {
+		return
+	}
+
+	if owner == "" {
+		owner = "root"
+	}
+
+	if group == "" {
+		group = "root"
+	}
+
+	if isDir {
+		dir := action.NewDir()
+		dir.Path = objPath
+		dir.Owner = owner
+		dir.Group = group
+
+		i.Manifest.Add(dir)
+		return
+	}
+
+	file := i.file(objPath)
+	file.Owner = owner
+	file.Group = group
+}
+
+func (i *Import) Config(objPath string) {
+	i.file(objPath).Config = true
+}
+
+func (i *Import) file(objPath string) *action.File {
+	for _, file := range i.Manifest.Files {
+		if file.Path == objPath {
+			return file
+		}
+	}
+
+	file := action.NewFile()
+	file.Path = objPath
+	i.Manifest.Add(file)
+
+	return file
+}
+
+// ToZpkgfile renders the imported metadata, fs objects without special attributes are
+// picked up from the proto tree by the builder
+func (i *Import) ToZpkgfile() *hclwrite.File {
+	file := hclwrite.NewEmptyFile()
+	body := file.Body()
+	zpkg := i.Manifest.Zpkg
+
+	block := body.AppendNewBlock("Zpkg", []string{zpkg.Name})
+	block.Body().SetAttributeValue("publisher", cty.StringVal(zpkg.Publisher))
+	block.Body().SetAttributeValue("version", cty.StringVal(zpkg.Version))
+	block.Body().SetAttributeValue("summary", cty.StringVal(zpkg.Summary))
+	block.Body().SetAttributeValue("description", cty.StringVal(zpkg.Description))
+	block.Body().SetAttributeValue("os", cty.StringVal(zpkg.Os))
+	block.Body().SetAttributeValue("arch", cty.StringVal(zpkg.Arch))
+
+	for _, req := range i.Manifest.Requirements {
+		body.AppendNewline()
+
+		block := body.AppendNewBlock("Requirement", []string{req.Name})
+		block.Body().SetAttributeValue("method", cty.StringVal(req.Method))
+
+		if req.Operation != "" {
+			block.Body().SetAttributeValue("operation", cty.StringVal(req.Operation))
+		}
+
+		if req.Version != "" {
+			block.Body().SetAttributeValue("version", cty.StringVal(req.Version))
+		}
+	}
+
+	for _, dir := range i.Manifest.Dirs {
+		body.AppendNewline()
+
+		block := body.AppendNewBlock("Dir", []string{dir.Path})
+		block.Body().SetAttributeValue("owner", cty.StringVal(dir.Owner))
+		block.Body().SetAttributeValue("group", cty.StringVal(dir.Group))
+	}
+
+	for _, f := range i.Manifest.Files {
+		body.AppendNewline()
+
+		block := body.AppendNewBlock("File", []string{f.Path})
+
+		if f.Owner != "" {
+			block.Body().SetAttributeValue("owner", cty.StringVal(f.Owner))
+			block.Body().SetAttributeValue("group", cty.StringVal(f.Group))
+		}
+
+		if f.Config {
+			block.Body().SetAttributeValue("config", cty.True)
+		}
+	}
+
+	return file
+}
+
+// importTar takes the package name and version from a name-version.tar.* file name
+func importTar(archivePath string, protoPath string) (*Import, error) {
+	imp := NewImport()
+
+	base := tarballName(filepath.Base(archivePath))
+	if index := strings.LastIndex(base, "-"); index > 0 {
+		if version, _, err := ImportVersion(base[index+1:]); err == nil {
+			imp.Manifest.Zpkg.Name = base[:index]
+			imp.Manifest.Zpkg.Version = version
+		}
+	}
+
+	if imp.Manifest.Zpkg.Name == "" {
+		imp.Manifest.Zpkg.Name = base
+	}
+
+	imp.Manifest.Zpkg.Os = "linux"
+	imp.Manifest.Zpkg.Arch, _ = ImportArch("")
+	imp.Manifest.Zpkg.Summary = imp.Manifest.Zpkg.Name
+	imp.Manifest.Zpkg.Description = fmt.Sprint("Imported from ", filepath.Base(archivePath))
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	err = imp.extractTar(file, protoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+// extractTar unpacks a possibly compressed tar stream, recording ownership and the newest mtime
+func (i *Import) extractTar(reader io.Reader, protoPath string) error {
+	stream, wait, err := decompress(reader)
+	if err != nil {
+		return err
+	}
+
+	archive := tar.NewReader(stream)
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		objPath := strings.TrimPrefix(filepath.Clean("/"+header.Name), "/")
+		if objPath == "" {
+			continue
+		}
+
+		target, err := importTarget(protoPath, objPath)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+			if err == nil {
+				err = os.Chmod(target, header.FileInfo().Mode().Perm())
+			}
+
+			i.Owner(objPath, true, header.Uname, header.Gname)
+		case tar.TypeReg, tar.TypeRegA:
+			err = writeImportFile(target, archive, header.FileInfo().Mode())
+
+			i.Owner(objPath, false, header.Uname, header.Gname)
+		case tar.TypeSymlink:
+			os.Remove(target)
+			err = os.Symlink(header.Linkname, target)
+		case tar.TypeLink:
+			// Hard links are imported as copies
+			var source string
+			source, err = importTarget(protoPath, strings.TrimPrefix(filepath.Clean("/"+header.Linkname), "/"))
+			if err == nil {
+				err = copyImportFile(source, target)
+			}
+		default:
+			i.Warn("%s skipped, unsupported tar entry type %c", objPath, header.Typeflag)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if header.ModTime.Unix() > 0 && header.ModTime.After(i.BuildTime) {
+			i.BuildTime = header.ModTime
+		}
+	}
+
+	return wait()
+}
+
+// importTarget resolves an archive path inside the proto tree, symlinks unpacked earlier
+// must not redirect writes outside of it
+func importTarget(protoPath string, objPath string) (string, error) {
+	target := filepath.Join(protoPath, filepath.Clean("/"+objPath))
+
+	root, err := filepath.EvalSymlinks(protoPath)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Dir(target)
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			break
+		}
+
+		dir = filepath.Dir(dir)
+	}
+
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if resolved != root && !strings.HasPrefix(resolved, root+string(os.PathSeparator)) {
+		return "", fmt.Errorf("archive entry %s escapes the proto tree", objPath)
+	}
+
+	return target, os.MkdirAll(filepath.Dir(target), 0755)
+}
+
+func writeImportFile(target string, content io.Reader, mode os.FileMode) error {
+	os.Remove(target)
+
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(target, mode&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky))
+}
+
+func copyImportFile(source string, target string) error {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("hard link target %s is not a regular file", source)
+	}
+
+	file, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return writeImportFile(target, file, info.Mode())
+}
+
+// decompress detects the stream compression, xz and lzma are handed to the xz utility
+func decompress(reader io.Reader) (io.Reader, func() error, error) {
+	buffered := bufio.NewReader(reader)
+	none := func() error { return nil }
+
+	magic, _ := buffered.Peek(6)
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		stream, err := gzip.NewReader(buffered)
+		return stream, none, err
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return bzip2.NewReader(buffered), none, nil
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		stream, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return stream, func() error { stream.Close(); return nil }, nil
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}), bytes.HasPrefix(magic, []byte{0x5d, 0x00, 0x00}):
+		cmd := exec.Command("xz", "--decompress", "--stdout")
+		cmd.Stdin = buffered
+
+		stream, err := cmd.StdoutPipe()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = cmd.Start()
+		if err != nil {
+			return nil, nil, errors.New("xz and lzma compressed archives require the xz utility")
+		}
+
+		return stream, cmd.Wait, nil
+	}
+
+	return buffered, none, nil
+}
+
+// tarballName strips a tarball extension, other names are returned unchanged
+func tarballName(name string) string {
+	suffixes := []string{".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz", ".tar.zst", ".tar"}
+	sort.Slice(suffixes, func(a, b int) bool { return len(suffixes[a]) > len(suffixes[b]) })
+
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return strings.TrimSuffix(name, suffix)
+		}
+	}
+
+	return name
+}
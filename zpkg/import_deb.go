@@ -0,0 +1,268 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const debArMagic = "!<arch>\n"
+
+var debRelationRe = regexp.MustCompile(`^([^\s(:]+)(?::\S+)?\s*(?:\(\s*(<<|<=|=|>=|>>|<|>)\s*([^)\s]+)\s*\))?`)
+
+// Relation operators mapped to range operators, bare < and > are obsolete forms of <= and >=
+var debOperators = map[string]string{
+	"<<": "<",
+	"<=": "<=",
+	"=":  "==",
+	">=": ">=",
+	">>": ">",
+	"<":  "<=",
+	">":  ">=",
+}
+
+var debScripts = []string{"preinst", "postinst", "prerm", "postrm", "config", "triggers", "templates"}
+
+func importDeb(archivePath string, protoPath string) (*Import, error) {
+	imp := NewImport()
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	magic := make([]byte, len(debArMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != debArMagic {
+		return nil, fmt.Errorf("%s is not a deb archive", filepath.Base(archivePath))
+	}
+
+	var control map[string]string
+
+	for {
+		header := make([]byte, 60)
+		_, err := io.ReadFull(reader, header)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := strings.TrimSuffix(strings.TrimSpace(string(header[0:16])), "/")
+
+		size, err := strconv.ParseInt(strings.TrimSpace(string(header[48:58])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid deb member header for %s", name)
+		}
+
+		member := io.LimitReader(reader, size)
+
+		switch {
+		case strings.HasPrefix(name, "control.tar"):
+			control, err = imp.debControl(member)
+		case strings.HasPrefix(name, "data.tar"):
+			if control == nil {
+				return nil, errors.New("deb data archive precedes control archive")
+			}
+
+			err = imp.extractTar(member, protoPath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// Drain the member and its padding to an even offset
+		if _, err := io.Copy(ioutil.Discard, member); err != nil {
+			return nil, err
+		}
+
+		if size%2 == 1 {
+			reader.Discard(1)
+		}
+	}
+
+	if control == nil {
+		return nil, errors.New("deb control archive not found")
+	}
+
+	return imp, imp.debMetadata(control)
+}
+
+// debControl reads the control archive, conffiles are marked once the payload is known
+func (i *Import) debControl(member io.Reader) (map[string]string, error) {
+	stream, wait, err := decompress(member)
+	if err != nil {
+		return nil, err
+	}
+
+	var control map[string]string
+	archive := tar.NewReader(stream)
+
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(header.Name)
+
+		switch name {
+		case "control":
+			content, err := ioutil.ReadAll(archive)
+			if err != nil {
+				return nil, err
+			}
+
+			control = debFields(content)
+		case "conffiles":
+			content, err := ioutil.ReadAll(archive)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, line := range strings.Split(string(content), "\n") {
+				line = strings.TrimSpace(line)
+				// Obsolete conffiles are flagged and no longer shipped
+				if line == "" || strings.Contains(line, " ") {
+					continue
+				}
+
+				i.Config(strings.TrimPrefix(filepath.Clean(line), "/"))
+			}
+		default:
+			for _, script := range debScripts {
+				if name == script {
+					i.Unsupported = append(i.Unsupported, fmt.Sprint("maintainer script ", name))
+				}
+			}
+		}
+	}
+
+	if control == nil {
+		return nil, errors.New("deb control file not found")
+	}
+
+	return control, wait()
+}
+
+func (i *Import) debMetadata(control map[string]string) error {
+	zpkg := i.Manifest.Zpkg
+	zpkg.Name = control["Package"]
+	zpkg.Os = "linux"
+
+	version, exact, err := ImportVersion(control["Version"])
+	if err != nil {
+		return err
+	}
+
+	if !exact {
+		i.Warn("version %s imported as %s", control["Version"], version)
+	}
+
+	zpkg.Version = version
+
+	zpkg.Arch, err = ImportArch(control["Architecture"])
+	if err != nil {
+		return err
+	}
+
+	description := strings.SplitN(control["Description"], "\n", 2)
+	zpkg.Summary = strings.TrimSpace(description[0])
+	zpkg.Description = zpkg.Summary
+
+	if len(description) == 2 {
+		var lines []string
+		for _, line := range strings.Split(description[1], "\n") {
+			line = strings.TrimPrefix(line, " ")
+			if line == "." {
+				line = ""
+			}
+
+			lines = append(lines, line)
+		}
+
+		zpkg.Description = strings.TrimSpace(strings.Join(lines, "\n"))
+	}
+
+	i.debRelations("depends", control["Pre-Depends"])
+	i.debRelations("depends", control["Depends"])
+	i.debRelations("conflicts", control["Conflicts"])
+	i.debRelations("conflicts", control["Breaks"])
+	i.debRelations("provides", control["Provides"])
+
+	return nil
+}
+
+func (i *Import) debRelations(method string, field string) {
+	for _, relation := range strings.Split(field, ",") {
+		relation = strings.TrimSpace(relation)
+		if relation == "" {
+			continue
+		}
+
+		alternatives := strings.Split(relation, "|")
+		if len(alternatives) > 1 {
+			i.Warn("%s %s imported as %s", method, relation, strings.TrimSpace(alternatives[0]))
+		}
+
+		match := debRelationRe.FindStringSubmatch(strings.TrimSpace(alternatives[0]))
+		if match == nil {
+			i.Warn("%s %s skipped, unable to parse", method, relation)
+			continue
+		}
+
+		i.Requirement(method, match[1], debOperators[match[2]], match[3])
+	}
+}
+
+// debFields parses a single control paragraph, continuation lines are kept newline separated
+func debFields(content []byte) map[string]string {
+	fields := make(map[string]string)
+	var last string
+
+	for _, line := range strings.Split(string(bytes.TrimSpace(content)), "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if last != "" {
+				fields[last] += "\n" + line
+			}
+
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		last = strings.TrimSpace(parts[0])
+		fields[last] = strings.TrimSpace(parts[1])
+	}
+
+	return fields
+}
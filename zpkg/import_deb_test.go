@@ -0,0 +1,268 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fezz-io/zps/action"
+)
+
+func TestDebRelations(t *testing.T) {
+	tests := []struct {
+		name     string
+		fields   [][2]string
+		expected []action.Requirement
+		warnings int
+	}{
+		{
+			name:     "unversioned",
+			fields:   [][2]string{{"depends", "libc6"}},
+			expected: []action.Requirement{{Name: "libc6", Method: "depends", Operation: "ANY"}},
+		},
+		{
+			name:     "versioned",
+			fields:   [][2]string{{"depends", "libc6 (>= 2.17)"}},
+			expected: []action.Requirement{{Name: "libc6", Method: "depends", Version: ">=2.17.0"}},
+		},
+		{
+			name:     "arch qualifier",
+			fields:   [][2]string{{"depends", "python3:any (>= 3.8), libfoo:amd64"}},
+			expected: []action.Requirement{{Name: "python3", Method: "depends", Version: ">=3.8.0"}, {Name: "libfoo", Method: "depends", Operation: "ANY"}},
+		},
+		{
+			name:     "alternatives",
+			fields:   [][2]string{{"depends", "exim4 (>= 4) | mail-transport-agent"}},
+			expected: []action.Requirement{{Name: "exim4", Method: "depends", Version: ">=4.0.0"}},
+			warnings: 1,
+		},
+		{
+			name:     "strict operators",
+			fields:   [][2]string{{"depends", "foo (<< 2)"}, {"conflicts", "bar (>> 3.1)"}},
+			expected: []action.Requirement{{Name: "foo", Method: "depends", Version: "<2.0.0"}, {Name: "bar", Method: "conflicts", Version: ">3.1.0"}},
+		},
+		{
+			name:     "obsolete operators",
+			fields:   [][2]string{{"depends", "foo (< 2)"}, {"conflicts", "bar (> 3.1)"}},
+			expected: []action.Requirement{{Name: "foo", Method: "depends", Version: "<=2.0.0"}, {Name: "bar", Method: "conflicts", Version: ">=3.1.0"}},
+		},
+		{
+			name:     "exact",
+			fields:   [][2]string{{"depends", "foo (= 1.2.3)"}},
+			expected: []action.Requirement{{Name: "foo", Method: "depends", Version: "==1.2.3"}},
+		},
+		{
+			name:     "versioned provides",
+			fields:   [][2]string{{"provides", "foo (= 1.2)"}},
+			expected: []action.Requirement{{Name: "foo", Method: "provides", Operation: "EQ", Version: "1.2.0"}},
+		},
+		{
+			name:     "range",
+			fields:   [][2]string{{"depends", "foo (>= 1), foo (<< 2)"}},
+			expected: []action.Requirement{{Name: "foo", Method: "depends", Version: ">=1.0.0, <2.0.0"}},
+		},
+		{
+			name:     "unversioned then versioned",
+			fields:   [][2]string{{"depends", "foo, foo (>= 2)"}},
+			expected: []action.Requirement{{Name: "foo", Method: "depends", Version: ">=2.0.0"}},
+		},
+		{
+			name:     "versioned then unversioned",
+			fields:   [][2]string{{"depends", "foo (>= 2), foo"}},
+			expected: []action.Requirement{{Name: "foo", Method: "depends", Version: ">=2.0.0"}},
+		},
+		{
+			name:   "provides and conflicts",
+			fields: [][2]string{{"conflicts", "mail-transport-agent"}, {"provides", "mail-transport-agent"}},
+			expected: []action.Requirement{
+				{Name: "mail-transport-agent", Method: "conflicts", Operation: "ANY"},
+				{Name: "mail-transport-agent", Method: "provides"},
+			},
+		},
+		{
+			name:     "unparsable",
+			fields:   [][2]string{{"depends", "(>= 2)"}},
+			warnings: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			imp := NewImport()
+
+			for _, field := range test.fields {
+				imp.debRelations(field[0], field[1])
+			}
+
+			var requirements []action.Requirement
+			for _, req := range imp.Manifest.Requirements {
+				requirements = append(requirements, *req)
+			}
+
+			if !reflect.DeepEqual(requirements, test.expected) {
+				t.Errorf("requirements: got %+v, want %+v", requirements, test.expected)
+			}
+
+			if len(imp.Warnings) != test.warnings {
+				t.Errorf("warnings: got %q, want %d", imp.Warnings, test.warnings)
+			}
+		})
+	}
+}
+
+func TestImportDeb(t *testing.T) {
+	root, err := ioutil.TempDir("", "zpkg-deb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	control := testTarGz(t, []testTarEntry{
+		{name: "./control", content: "Package: foo\nVersion: 1:1.2-3\nArchitecture: amd64\n" +
+			"Depends: libc6 (>= 2.17)\nDescription: Foo tool\n Does foo.\n .\n And more.\n"},
+		{name: "./conffiles", content: "/etc/foo.conf\nremove-on-upgrade /etc/old.conf\n"},
+		{name: "./postinst", content: "#!/bin/sh\n"},
+		{name: "./md5sums", content: ""},
+	})
+
+	data := testTarGz(t, []testTarEntry{
+		{name: "./etc/", dir: true},
+		{name: "./etc/foo.conf", content: "key=value\n"},
+		{name: "./usr/", dir: true},
+		{name: "./usr/bin/", dir: true},
+		{name: "./usr/bin/foo", content: "foo", mode: 0755},
+	})
+
+	archivePath := filepath.Join(root, "foo_1.2-3_amd64.deb")
+	err = ioutil.WriteFile(archivePath, testAr([]testArMember{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", control},
+		{"data.tar.gz", data},
+	}), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imp, err := ImportArchive(archivePath, filepath.Join(root, "proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zpkg := imp.Manifest.Zpkg
+	if zpkg.Name != "foo" || zpkg.Version != "1.2.0" || zpkg.Arch != "x86_64" {
+		t.Errorf("zpkg: got %s %s %s", zpkg.Name, zpkg.Version, zpkg.Arch)
+	}
+
+	if zpkg.Summary != "Foo tool" || zpkg.Description != "Does foo.\n\nAnd more." {
+		t.Errorf("description: got %q %q", zpkg.Summary, zpkg.Description)
+	}
+
+	var configs []string
+	for _, file := range imp.Manifest.Files {
+		if file.Config {
+			configs = append(configs, file.Path)
+		}
+	}
+
+	if !reflect.DeepEqual(configs, []string{"etc/foo.conf"}) {
+		t.Errorf("config files: got %v", configs)
+	}
+
+	if !reflect.DeepEqual(imp.Unsupported, []string{"maintainer script postinst"}) {
+		t.Errorf("unsupported: got %v", imp.Unsupported)
+	}
+
+	if len(imp.Manifest.Requirements) != 1 || imp.Manifest.Requirements[0].Version != ">=2.17.0" {
+		t.Errorf("requirements: got %v", imp.Manifest.Requirements)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(root, "proto", "usr", "bin", "foo"))
+	if err != nil || string(content) != "foo" {
+		t.Errorf("payload: got %q, %v", content, err)
+	}
+}
+
+type testTarEntry struct {
+	name    string
+	content string
+	mode    int64
+	dir     bool
+}
+
+func testTarGz(t *testing.T, entries []testTarEntry) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+
+	compressed := gzip.NewWriter(&buffer)
+	archive := tar.NewWriter(compressed)
+
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: entry.mode, Size: int64(len(entry.content)), Typeflag: tar.TypeReg}
+		if header.Mode == 0 {
+			header.Mode = 0644
+		}
+
+		if entry.dir {
+			header.Typeflag = tar.TypeDir
+			header.Mode = 0755
+		}
+
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := archive.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := compressed.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
+
+type testArMember struct {
+	name    string
+	content []byte
+}
+
+// testAr writes a common ar archive as used by deb packages
+func testAr(members []testArMember) []byte {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(debArMagic)
+
+	for _, member := range members {
+		fmt.Fprintf(&buffer, "%-16s%-12d%-6d%-6d%-8o%-10d`\n", member.name, 0, 0, 0, 0644, len(member.content))
+		buffer.Write(member.content)
+
+		if len(member.content)%2 == 1 {
+			buffer.WriteByte('\n')
+		}
+	}
+
+	return buffer.Bytes()
+}
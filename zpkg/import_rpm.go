@@ -0,0 +1,526 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	rpmLeadSize   = 96
+	rpmCpioHeader = 110
+)
+
+var rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+// Header tags used by import
+const (
+	rpmTagName           = 1000
+	rpmTagVersion        = 1001
+	rpmTagSummary        = 1004
+	rpmTagDescription    = 1005
+	rpmTagBuildTime      = 1006
+	rpmTagOs             = 1021
+	rpmTagArch           = 1022
+	rpmTagPreIn          = 1023
+	rpmTagPostIn         = 1024
+	rpmTagPreUn          = 1025
+	rpmTagPostUn         = 1026
+	rpmTagOldFileNames   = 1027
+	rpmTagFileFlags      = 1037
+	rpmTagFileUserName   = 1039
+	rpmTagFileGroupName  = 1040
+	rpmTagProvideName    = 1047
+	rpmTagRequireFlags   = 1048
+	rpmTagRequireName    = 1049
+	rpmTagRequireVersion = 1050
+	rpmTagConflictFlags  = 1053
+	rpmTagConflictName   = 1054
+	rpmTagConflictVer    = 1055
+	rpmTagTriggerScripts = 1065
+	rpmTagProvideFlags   = 1112
+	rpmTagProvideVersion = 1113
+	rpmTagDirIndexes     = 1116
+	rpmTagBaseNames      = 1117
+	rpmTagDirNames       = 1118
+	rpmTagPreTrans       = 1151
+	rpmTagPostTrans      = 1152
+)
+
+const (
+	rpmSenseLess    = 0x02
+	rpmSenseGreater = 0x04
+	rpmSenseEqual   = 0x08
+	rpmFileConfig   = 0x01
+)
+
+var rpmScripts = []struct {
+	tag  int
+	name string
+}{
+	{rpmTagPreTrans, "%pretrans"},
+	{rpmTagPreIn, "%pre"},
+	{rpmTagPostIn, "%post"},
+	{rpmTagPreUn, "%preun"},
+	{rpmTagPostUn, "%postun"},
+	{rpmTagPostTrans, "%posttrans"},
+	{rpmTagTriggerScripts, "%trigger"},
+}
+
+type rpmHeader struct {
+	entries map[int]rpmEntry
+	store   []byte
+}
+
+type rpmFile struct {
+	owner  string
+	group  string
+	config bool
+}
+
+type rpmEntry struct {
+	kind   uint32
+	offset uint32
+	count  uint32
+}
+
+func importRpm(archivePath string, protoPath string) (*Import, error) {
+	imp := NewImport()
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	lead := make([]byte, rpmLeadSize)
+	if _, err := io.ReadFull(reader, lead); err != nil || !bytes.Equal(lead[0:4], []byte{0xed, 0xab, 0xee, 0xdb}) {
+		return nil, fmt.Errorf("%s is not an rpm archive", filepath.Base(archivePath))
+	}
+
+	// The signature header is padded to an eight byte boundary
+	signature, size, err := readRpmHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if signature != nil && size%8 != 0 {
+		reader.Discard(8 - size%8)
+	}
+
+	header, _, err := readRpmHeader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	err = imp.rpmMetadata(header)
+	if err != nil {
+		return nil, err
+	}
+
+	err = imp.extractRpmPayload(reader, protoPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return imp, nil
+}
+
+func readRpmHeader(reader io.Reader) (*rpmHeader, int, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(reader, intro); err != nil || !bytes.Equal(intro[0:4], rpmHeaderMagic) {
+		return nil, 0, errors.New("invalid rpm header")
+	}
+
+	count := binary.BigEndian.Uint32(intro[8:12])
+	storeSize := binary.BigEndian.Uint32(intro[12:16])
+
+	index := make([]byte, count*16)
+	if _, err := io.ReadFull(reader, index); err != nil {
+		return nil, 0, err
+	}
+
+	header := &rpmHeader{entries: make(map[int]rpmEntry), store: make([]byte, storeSize)}
+	if _, err := io.ReadFull(reader, header.store); err != nil {
+		return nil, 0, err
+	}
+
+	for offset := 0; offset < len(index); offset += 16 {
+		tag := int(binary.BigEndian.Uint32(index[offset : offset+4]))
+
+		header.entries[tag] = rpmEntry{
+			kind:   binary.BigEndian.Uint32(index[offset+4 : offset+8]),
+			offset: binary.BigEndian.Uint32(index[offset+8 : offset+12]),
+			count:  binary.BigEndian.Uint32(index[offset+12 : offset+16]),
+		}
+	}
+
+	return header, len(intro) + len(index) + len(header.store), nil
+}
+
+// Strings returns string, string array and i18n string values
+func (h *rpmHeader) Strings(tag int) []string {
+	entry, ok := h.entries[tag]
+	if !ok || int(entry.offset) > len(h.store) {
+		return nil
+	}
+
+	count := int(entry.count)
+	if entry.kind == 6 {
+		count = 1
+	}
+
+	var values []string
+	data := h.store[entry.offset:]
+
+	for len(values) < count {
+		end := bytes.IndexByte(data, 0)
+		if end == -1 {
+			break
+		}
+
+		values = append(values, string(data[:end]))
+		data = data[end+1:]
+	}
+
+	return values
+}
+
+func (h *rpmHeader) String(tag int) string {
+	values := h.Strings(tag)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// Ints returns int16 and int32 values
+func (h *rpmHeader) Ints(tag int) []int {
+	entry, ok := h.entries[tag]
+	if !ok {
+		return nil
+	}
+
+	var values []int
+
+	for index := 0; index < int(entry.count); index++ {
+		switch entry.kind {
+		case 3:
+			offset := int(entry.offset) + index*2
+			if offset+2 > len(h.store) {
+				return values
+			}
+
+			values = append(values, int(binary.BigEndian.Uint16(h.store[offset:])))
+		case 4:
+			offset := int(entry.offset) + index*4
+			if offset+4 > len(h.store) {
+				return values
+			}
+
+			values = append(values, int(binary.BigEndian.Uint32(h.store[offset:])))
+		}
+	}
+
+	return values
+}
+
+func (i *Import) rpmMetadata(header *rpmHeader) error {
+	zpkg := i.Manifest.Zpkg
+	zpkg.Name = header.String(rpmTagName)
+	zpkg.Os = strings.ToLower(header.String(rpmTagOs))
+	zpkg.Summary = header.String(rpmTagSummary)
+	zpkg.Description = strings.TrimSpace(header.String(rpmTagDescription))
+
+	// Releases are distribution revisions with no zps equivalent
+	version, exact, err := ImportVersion(header.String(rpmTagVersion))
+	if err != nil {
+		return err
+	}
+
+	if !exact {
+		i.Warn("version %s imported as %s", header.String(rpmTagVersion), version)
+	}
+
+	zpkg.Version = version
+
+	zpkg.Arch, err = ImportArch(header.String(rpmTagArch))
+	if err != nil {
+		return err
+	}
+
+	if buildTime := header.Ints(rpmTagBuildTime); len(buildTime) > 0 {
+		i.BuildTime = time.Unix(int64(buildTime[0]), 0).UTC()
+	}
+
+	for _, script := range rpmScripts {
+		if _, ok := header.entries[script.tag]; ok {
+			i.Unsupported = append(i.Unsupported, fmt.Sprint("scriptlet ", script.name))
+		}
+	}
+
+	i.rpmRelations("depends", header, rpmTagRequireName, rpmTagRequireFlags, rpmTagRequireVersion)
+	i.rpmRelations("conflicts", header, rpmTagConflictName, rpmTagConflictFlags, rpmTagConflictVer)
+	i.rpmRelations("provides", header, rpmTagProvideName, rpmTagProvideFlags, rpmTagProvideVersion)
+
+	paths := rpmPaths(header)
+	flags := header.Ints(rpmTagFileFlags)
+	owners := header.Strings(rpmTagFileUserName)
+	groups := header.Strings(rpmTagFileGroupName)
+
+	// Attributes are applied once the payload shows which paths are shipped and their types
+	i.rpmFiles = make(map[string]rpmFile)
+	for index, objPath := range paths {
+		var attributes rpmFile
+
+		if index < len(owners) && index < len(groups) {
+			attributes.owner, attributes.group = owners[index], groups[index]
+		}
+
+		if index < len(flags) {
+			attributes.config = flags[index]&rpmFileConfig != 0
+		}
+
+		i.rpmFiles[objPath] = attributes
+	}
+
+	return nil
+}
+
+func (i *Import) rpmRelations(method string, header *rpmHeader, nameTag int, flagsTag int, versionTag int) {
+	names := header.Strings(nameTag)
+	flags := header.Ints(flagsTag)
+	versions := header.Strings(versionTag)
+
+	for index, name := range names {
+		// Internal rpm capabilities, file paths, rich dependencies and the package itself
+		if strings.HasPrefix(name, "rpmlib(") || strings.HasPrefix(name, "/") || strings.HasPrefix(name, "(") ||
+			strings.HasPrefix(name, "config(") || name == i.Manifest.Zpkg.Name {
+			continue
+		}
+
+		var operator, version string
+
+		if index < len(versions) && index < len(flags) && versions[index] != "" {
+			version = versions[index]
+
+			// Releases are dropped along with the package release
+			if dash := strings.LastIndex(version, "-"); dash != -1 {
+				version = version[:dash]
+			}
+
+			switch flags[index] & (rpmSenseLess | rpmSenseGreater | rpmSenseEqual) {
+			case rpmSenseLess:
+				operator = "<"
+			case rpmSenseLess | rpmSenseEqual:
+				operator = "<="
+			case rpmSenseEqual:
+				operator = "=="
+			case rpmSenseGreater | rpmSenseEqual:
+				operator = ">="
+			case rpmSenseGreater:
+				operator = ">"
+			}
+		}
+
+		i.Requirement(method, name, operator, version)
+	}
+}
+
+func rpmPaths(header *rpmHeader) []string {
+	var paths []string
+
+	if names := header.Strings(rpmTagOldFileNames); len(names) > 0 {
+		for _, name := range names {
+			paths = append(paths, strings.TrimPrefix(filepath.Clean(name), "/"))
+		}
+
+		return paths
+	}
+
+	baseNames := header.Strings(rpmTagBaseNames)
+	dirNames := header.Strings(rpmTagDirNames)
+	dirIndexes := header.Ints(rpmTagDirIndexes)
+
+	for index, baseName := range baseNames {
+		if index >= len(dirIndexes) || dirIndexes[index] >= len(dirNames) {
+			break
+		}
+
+		paths = append(paths, strings.TrimPrefix(filepath.Clean(dirNames[dirIndexes[index]]+baseName), "/"))
+	}
+
+	return paths
+}
+
+// extractRpmPayload unpacks the cpio newc payload, hard links are imported as copies
+func (i *Import) extractRpmPayload(reader io.Reader, protoPath string) error {
+	stream, wait, err := decompress(reader)
+	if err != nil {
+		return err
+	}
+
+	payload := bufio.NewReader(stream)
+	links := make(map[string][]string)
+
+	for {
+		fields := make([]byte, rpmCpioHeader)
+		if _, err := io.ReadFull(payload, fields); err != nil {
+			return err
+		}
+
+		if string(fields[0:6]) != "070701" && string(fields[0:6]) != "070702" {
+			return errors.New("unsupported rpm payload format")
+		}
+
+		value := func(field int) int64 {
+			parsed, _ := strconv.ParseInt(string(fields[6+field*8:14+field*8]), 16, 64)
+			return parsed
+		}
+
+		ino, mode, size, nameSize := value(0), value(1), value(6), value(11)
+
+		name := make([]byte, nameSize)
+		if _, err := io.ReadFull(payload, name); err != nil {
+			return err
+		}
+
+		payload.Discard(cpioPad(rpmCpioHeader + nameSize))
+
+		objPath := strings.TrimPrefix(filepath.Clean("/"+string(bytes.TrimRight(name, "\x00"))), "/")
+		if objPath == "TRAILER!!!" {
+			break
+		}
+
+		content := io.LimitReader(payload, size)
+
+		err = i.extractCpioEntry(protoPath, objPath, os.FileMode(mode), content, links, fmt.Sprint(ino, ":", value(2)))
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(ioutil.Discard, content); err != nil {
+			return err
+		}
+
+		payload.Discard(cpioPad(size))
+	}
+
+	return wait()
+}
+
+func (i *Import) extractCpioEntry(protoPath string, objPath string, mode os.FileMode, content io.Reader, links map[string][]string, inode string) error {
+	if objPath == "" {
+		return nil
+	}
+
+	target, err := importTarget(protoPath, objPath)
+	if err != nil {
+		return err
+	}
+
+	attributes := i.rpmFiles[objPath]
+	perm := mode.Perm() | cpioSpecial(mode)
+
+	switch mode & 0170000 {
+	case 0040000:
+		err = os.MkdirAll(target, 0755)
+		if err == nil {
+			err = os.Chmod(target, perm)
+		}
+
+		i.Owner(objPath, true, attributes.owner, attributes.group)
+	case 0100000:
+		err = writeImportFile(target, content, perm)
+
+		// Hard linked files carry content on the last link only
+		if err == nil && len(links[inode]) > 0 {
+			err = i.relinkCpio(protoPath, links[inode], target)
+		}
+
+		links[inode] = append(links[inode], objPath)
+
+		i.Owner(objPath, false, attributes.owner, attributes.group)
+
+		if attributes.config {
+			i.Config(objPath)
+		}
+	case 0120000:
+		var linkTarget []byte
+		linkTarget, err = ioutil.ReadAll(content)
+		if err == nil {
+			os.Remove(target)
+			err = os.Symlink(string(linkTarget), target)
+		}
+	default:
+		i.Warn("%s skipped, unsupported file type %o", objPath, mode&0170000)
+	}
+
+	return err
+}
+
+// relinkCpio copies hard link content onto earlier, empty links of the same inode
+func (i *Import) relinkCpio(protoPath string, earlier []string, target string) error {
+	info, err := os.Stat(target)
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+
+	for _, link := range earlier {
+		linkTarget, err := importTarget(protoPath, link)
+		if err != nil {
+			return err
+		}
+
+		err = copyImportFile(target, linkTarget)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func cpioPad(size int64) int {
+	return int((4 - size%4) % 4)
+}
+
+func cpioSpecial(mode os.FileMode) os.FileMode {
+	var special os.FileMode
+
+	if mode&04000 != 0 {
+		special |= os.ModeSetuid
+	}
+
+	if mode&02000 != 0 {
+		special |= os.ModeSetgid
+	}
+
+	if mode&01000 != 0 {
+		special |= os.ModeSticky
+	}
+
+	return special
+}
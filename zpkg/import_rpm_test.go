@@ -0,0 +1,224 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpkg
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/fezz-io/zps/action"
+)
+
+func TestRpmRelations(t *testing.T) {
+	tests := []struct {
+		name     string
+		flags    int
+		version  string
+		expected action.Requirement
+	}{
+		{"unversioned", 0, "", action.Requirement{Name: "foo", Method: "depends", Operation: "ANY"}},
+		{"less", rpmSenseLess, "2", action.Requirement{Name: "foo", Method: "depends", Version: "<2.0.0"}},
+		{"less or equal", rpmSenseLess | rpmSenseEqual, "2", action.Requirement{Name: "foo", Method: "depends", Version: "<=2.0.0"}},
+		{"equal", rpmSenseEqual, "1.2.3", action.Requirement{Name: "foo", Method: "depends", Version: "==1.2.3"}},
+		{"greater or equal", rpmSenseGreater | rpmSenseEqual, "1.2", action.Requirement{Name: "foo", Method: "depends", Version: ">=1.2.0"}},
+		{"greater", rpmSenseGreater, "1.2", action.Requirement{Name: "foo", Method: "depends", Version: ">1.2.0"}},
+		{"release dropped", rpmSenseGreater | rpmSenseEqual, "1.2-3.el8", action.Requirement{Name: "foo", Method: "depends", Version: ">=1.2.0"}},
+		{"other sense bits", 0x1000 | rpmSenseEqual, "1.0", action.Requirement{Name: "foo", Method: "depends", Version: "==1.0.0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := testRpmHeader(map[int]interface{}{
+				rpmTagRequireName:    []string{"rpmlib(PayloadFilesHavePrefix)", "/bin/sh", "foo"},
+				rpmTagRequireFlags:   []int{rpmSenseLess | rpmSenseEqual, 0, test.flags},
+				rpmTagRequireVersion: []string{"4.0-1", "", test.version},
+			})
+
+			imp := NewImport()
+			imp.rpmRelations("depends", header, rpmTagRequireName, rpmTagRequireFlags, rpmTagRequireVersion)
+
+			if len(imp.Manifest.Requirements) != 1 || !reflect.DeepEqual(*imp.Manifest.Requirements[0], test.expected) {
+				t.Errorf("got %+v, want %+v", imp.Manifest.Requirements, test.expected)
+			}
+		})
+	}
+}
+
+func TestImportRpm(t *testing.T) {
+	root, err := ioutil.TempDir("", "zpkg-rpm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	header := testRpmHeader(map[int]interface{}{
+		rpmTagName:           []string{"foo"},
+		rpmTagVersion:        []string{"1.2.3"},
+		rpmTagSummary:        []string{"Foo tool"},
+		rpmTagDescription:    []string{"Does foo.\n"},
+		rpmTagOs:             []string{"linux"},
+		rpmTagArch:           []string{"x86_64"},
+		rpmTagPostIn:         []string{"/sbin/ldconfig"},
+		rpmTagBaseNames:      []string{"etc", "foo.conf", "foo"},
+		rpmTagDirNames:       []string{"/", "/etc/"},
+		rpmTagDirIndexes:     []int{0, 1, 0},
+		rpmTagFileFlags:      []int{0, rpmFileConfig, 0},
+		rpmTagFileUserName:   []string{"root", "root", "root"},
+		rpmTagFileGroupName:  []string{"root", "root", "root"},
+		rpmTagProvideName:    []string{"foo", "foo-tool"},
+		rpmTagProvideFlags:   []int{rpmSenseEqual, rpmSenseEqual},
+		rpmTagProvideVersion: []string{"1.2.3-1", "1.2.3-1"},
+	})
+
+	var archive bytes.Buffer
+
+	lead := make([]byte, rpmLeadSize)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb})
+	archive.Write(lead)
+
+	// An empty signature header needs no padding
+	archive.Write(testRpmHeaderBytes(testRpmHeader(map[int]interface{}{})))
+	archive.Write(testRpmHeaderBytes(header))
+	archive.Write(testCpioGz(t, []testCpioEntry{
+		{"./etc", 0040755, ""},
+		{"./etc/foo.conf", 0100644, "key=value\n"},
+		{"./foo", 0100755, "foo"},
+	}))
+
+	archivePath := filepath.Join(root, "foo-1.2.3-1.x86_64.rpm")
+	err = ioutil.WriteFile(archivePath, archive.Bytes(), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imp, err := ImportArchive(archivePath, filepath.Join(root, "proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	zpkg := imp.Manifest.Zpkg
+	if zpkg.Name != "foo" || zpkg.Version != "1.2.3" || zpkg.Arch != "x86_64" || zpkg.Os != "linux" {
+		t.Errorf("zpkg: got %s %s %s %s", zpkg.Name, zpkg.Version, zpkg.Arch, zpkg.Os)
+	}
+
+	var configs []string
+	for _, file := range imp.Manifest.Files {
+		if file.Config {
+			configs = append(configs, file.Path)
+		}
+	}
+
+	if !reflect.DeepEqual(configs, []string{"etc/foo.conf"}) {
+		t.Errorf("config files: got %v", configs)
+	}
+
+	if !reflect.DeepEqual(imp.Unsupported, []string{"scriptlet %post"}) {
+		t.Errorf("unsupported: got %v", imp.Unsupported)
+	}
+
+	// The package providing itself is implied
+	expected := action.Requirement{Name: "foo-tool", Method: "provides", Operation: "EQ", Version: "1.2.3"}
+	if len(imp.Manifest.Requirements) != 1 || !reflect.DeepEqual(*imp.Manifest.Requirements[0], expected) {
+		t.Errorf("requirements: got %+v", imp.Manifest.Requirements)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(root, "proto", "etc", "foo.conf"))
+	if err != nil || string(content) != "key=value\n" {
+		t.Errorf("payload: got %q, %v", content, err)
+	}
+}
+
+// testRpmHeader builds a header from string array and int32 values
+func testRpmHeader(values map[int]interface{}) *rpmHeader {
+	header := &rpmHeader{entries: make(map[int]rpmEntry)}
+
+	for tag, value := range values {
+		switch value := value.(type) {
+		case []string:
+			header.entries[tag] = rpmEntry{kind: 8, offset: uint32(len(header.store)), count: uint32(len(value))}
+
+			for _, item := range value {
+				header.store = append(append(header.store, item...), 0)
+			}
+		case []int:
+			for len(header.store)%4 != 0 {
+				header.store = append(header.store, 0)
+			}
+
+			header.entries[tag] = rpmEntry{kind: 4, offset: uint32(len(header.store)), count: uint32(len(value))}
+
+			for _, item := range value {
+				header.store = append(header.store, 0, 0, 0, 0)
+				binary.BigEndian.PutUint32(header.store[len(header.store)-4:], uint32(item))
+			}
+		}
+	}
+
+	return header
+}
+
+// testRpmHeaderBytes serializes a header, padding after a signature header is left to the caller
+func testRpmHeaderBytes(header *rpmHeader) []byte {
+	var buffer bytes.Buffer
+
+	buffer.Write(rpmHeaderMagic)
+	binary.Write(&buffer, binary.BigEndian, []uint32{0, uint32(len(header.entries)), uint32(len(header.store))})
+
+	for tag, entry := range header.entries {
+		binary.Write(&buffer, binary.BigEndian, []uint32{uint32(tag), entry.kind, entry.offset, entry.count})
+	}
+
+	buffer.Write(header.store)
+
+	return buffer.Bytes()
+}
+
+type testCpioEntry struct {
+	name    string
+	mode    int
+	content string
+}
+
+// testCpioGz writes a gzip compressed cpio newc archive
+func testCpioGz(t *testing.T, entries []testCpioEntry) []byte {
+	t.Helper()
+
+	var buffer bytes.Buffer
+	compressed := gzip.NewWriter(&buffer)
+
+	write := func(ino int, name string, mode int, content string) {
+		fmt.Fprintf(compressed, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			ino, mode, 0, 0, 1, 0, len(content), 0, 0, 0, 0, len(name)+1, 0)
+		fmt.Fprint(compressed, name, "\x00")
+		compressed.Write(make([]byte, cpioPad(int64(rpmCpioHeader+len(name)+1))))
+		fmt.Fprint(compressed, content)
+		compressed.Write(make([]byte, cpioPad(int64(len(content)))))
+	}
+
+	for index, entry := range entries {
+		write(index+1, entry.name, entry.mode, entry.content)
+	}
+
+	write(0, "TRAILER!!!", 0, "")
+
+	if err := compressed.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buffer.Bytes()
+}
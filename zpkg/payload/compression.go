@@ -0,0 +1,102 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package payload
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression ids as stored in the zpkg header
+const (
+	CompressionBzip2 uint8 = 0
+	CompressionZstd  uint8 = 1
+)
+
+// Default levels are pinned rather than taken from the compression libraries so that
+// stream parameters, and therefore zpkg bytes, do not change with library versions
+const (
+	DefaultBzip2Level = 7
+	DefaultZstdLevel  = 5
+)
+
+// Zstd levels follow the reference implementation, the encoder maps them onto its own speed levels
+const (
+	MinZstdLevel = 1
+	MaxZstdLevel = 22
+)
+
+// CompressionId resolves a compression name as accepted by zpkg build
+func CompressionId(name string) (uint8, error) {
+	switch name {
+	case "", "bzip2":
+		return CompressionBzip2, nil
+	case "zstd":
+		return CompressionZstd, nil
+	}
+
+	return 0, fmt.Errorf("unsupported compression: %s", name)
+}
+
+func CompressionName(compression uint8) string {
+	switch compression {
+	case CompressionBzip2:
+		return "bzip2"
+	case CompressionZstd:
+		return "zstd"
+	}
+
+	return fmt.Sprint("unknown (", compression, ")")
+}
+
+// NewCompressor wraps w with the given compression, a level of 0 selects the default
+func NewCompressor(w io.Writer, compression uint8, level int) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionBzip2:
+		if level == 0 {
+			level = DefaultBzip2Level
+		}
+
+		return bzip2.NewWriter(w, &bzip2.WriterConfig{Level: level})
+	case CompressionZstd:
+		if level == 0 {
+			level = DefaultZstdLevel
+		}
+
+		if level < MinZstdLevel || level > MaxZstdLevel {
+			return nil, fmt.Errorf("zstd compression level must be between %d and %d", MinZstdLevel, MaxZstdLevel)
+		}
+
+		// A single encoder goroutine keeps output independent of the host
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)), zstd.WithEncoderConcurrency(1))
+	}
+
+	return nil, fmt.Errorf("unsupported compression: %d", compression)
+}
+
+func NewDecompressor(r io.Reader, compression uint8) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionBzip2:
+		return bzip2.NewReader(r, &bzip2.ReaderConfig{})
+	case CompressionZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+
+		return decoder.IOReadCloser(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported compression: %d", compression)
+}
@@ -14,7 +14,6 @@ import (
 	"bufio"
 	"crypto/sha256"
 	"encoding/hex"
-	"github.com/dsnet/compress/bzip2"
 	"io"
 	"os"
 )
@@ -23,6 +22,9 @@ type Reader struct {
 	WorkPath string
 	Path     string
 
+	// Compression id from the zpkg header
+	Compression uint8
+
 	offset int64
 	file   *os.File
 }
@@ -60,19 +62,18 @@ func (r *Reader) Get(path string, offset int64, size int64) (string, error) {
 
 	reader := bufio.NewReader(r.file)
 
-	var bzreader *bzip2.Reader
-
-	bzreader, err = bzip2.NewReader(reader, &bzip2.ReaderConfig{})
+	decompressor, err := NewDecompressor(reader, r.Compression)
 	if err != nil {
 		return "", err
 	}
+	defer decompressor.Close()
 
 	writer := bufio.NewWriter(target)
 	hasher := sha256.New()
 
 	multi := io.MultiWriter(writer, hasher)
 
-	_, err = io.CopyN(multi, bzreader, size)
+	_, err = io.CopyN(multi, decompressor, size)
 
 	if err != nil {
 		return "", err
@@ -101,16 +102,15 @@ func (r *Reader) Verify(offset int64, size int64) (string, error) {
 
 	reader := bufio.NewReader(r.file)
 
-	var bzreader *bzip2.Reader
-
-	bzreader, err = bzip2.NewReader(reader, &bzip2.ReaderConfig{})
+	decompressor, err := NewDecompressor(reader, r.Compression)
 	if err != nil {
 		return "", err
 	}
+	defer decompressor.Close()
 
 	hasher := sha256.New()
 
-	_, err = io.CopyN(hasher, bzreader, size)
+	_, err = io.CopyN(hasher, decompressor, size)
 
 	if err != nil {
 		return "", err
@@ -17,13 +17,15 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
-
-	"github.com/dsnet/compress/bzip2"
 )
 
 type Writer struct {
 	WorkPath string
 
+	// Compression id and level, a level of 0 selects the default for the compression
+	Compression uint8
+	Level       int
+
 	offset int64
 	file   *os.File
 }
@@ -54,8 +56,11 @@ func (w *Writer) Put(path string) (int, int, string, error) {
 
 	reader := bufio.NewReader(src)
 
-	var writer *bzip2.Writer
-	writer, _ = bzip2.NewWriter(w.file, &bzip2.WriterConfig{Level: 7})
+	writer, err := NewCompressor(w.file, w.Compression, w.Level)
+	if err != nil {
+		src.Close()
+		return 0, 0, "", err
+	}
 
 	hasher := sha256.New()
 	multi := io.MultiWriter(writer, hasher)
@@ -65,7 +70,11 @@ func (w *Writer) Put(path string) (int, int, string, error) {
 		return 0, 0, "", err
 	}
 
-	writer.Close()
+	err = writer.Close()
+	if err != nil {
+		return 0, 0, "", err
+	}
+
 	src.Close()
 
 	currentOffset, err := w.file.Seek(0, os.SEEK_CUR)
@@ -17,7 +17,6 @@ import (
 	"io"
 	"os"
 
-	"github.com/dsnet/compress/bzip2"
 	"github.com/fezz-io/zps/action"
 	"github.com/fezz-io/zps/zpkg/payload"
 	"github.com/lunixbochs/struc"
@@ -71,15 +70,16 @@ func (r *Reader) Read() error {
 		return err
 	}
 
-	bzreader, err := bzip2.NewReader(&cManifestBytes, &bzip2.ReaderConfig{})
+	decompressor, err := payload.NewDecompressor(&cManifestBytes, r.Header.Compression)
 	if err != nil {
 		return err
 	}
+	defer decompressor.Close()
 
 	var manifestBytes bytes.Buffer
 	writer := io.Writer(&manifestBytes)
 
-	_, err = io.Copy(writer, bzreader)
+	_, err = io.Copy(writer, decompressor)
 	if err != nil {
 		return err
 	}
@@ -89,11 +89,18 @@ func (r *Reader) Read() error {
 		return err
 	}
 
+	// Packages are untrusted input, refuse manifests that escape the image root or are inconsistent
+	err = r.Manifest.Validate()
+	if err != nil {
+		return err
+	}
+
 	file.Close()
 
 	// TODO get byte size of header instead of just setting it
 	offset := int64(r.Header.ManifestLength + 12)
 	r.Payload = payload.NewReader(r.workPath, r.path, offset)
+	r.Payload.Compression = r.Header.Compression
 
 	return err
 }
@@ -59,7 +59,8 @@ func (s *Signer) Sign(fingerprint string, key *rsa.PrivateKey) error {
 	writer := NewWriter()
 	tmpFile := s.reader.path + ".signing"
 
-	err = writer.Write(tmpFile, NewHeader(Version, Compression), manifest, payload.NewWriter("", 0))
+	// Keep the compression of the existing payload
+	err = writer.Write(tmpFile, NewHeader(Version, s.reader.Header.Compression), manifest, payload.NewWriter("", 0))
 	if err != nil {
 		return err
 	}
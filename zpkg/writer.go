@@ -16,7 +16,6 @@ import (
 	"io"
 	"os"
 
-	"github.com/dsnet/compress/bzip2"
 	"github.com/fezz-io/zps/action"
 	"github.com/fezz-io/zps/zpkg/payload"
 	"github.com/lunixbochs/struc"
@@ -28,17 +27,20 @@ func NewWriter() *Writer {
 	return &Writer{}
 }
 
-func (w *Writer) Write(filename string, header *Header, manifest *action.Manifest, payload *payload.Writer) error {
+func (w *Writer) Write(filename string, header *Header, manifest *action.Manifest, contents *payload.Writer) error {
 	var manifestBuffer bytes.Buffer
 
 	// compress manifest
-	bzw, _ := bzip2.NewWriter(&manifestBuffer, &bzip2.WriterConfig{Level: 7})
+	compressor, err := payload.NewCompressor(&manifestBuffer, header.Compression, 0)
+	if err != nil {
+		return err
+	}
 
-	if _, err := io.WriteString(bzw, manifest.ToJson()); err != nil {
+	if _, err := io.WriteString(compressor, manifest.ToJson()); err != nil {
 		return err
 	}
 
-	if err := bzw.Close(); err != nil {
+	if err := compressor.Close(); err != nil {
 		return err
 	}
 
@@ -61,9 +63,9 @@ func (w *Writer) Write(filename string, header *Header, manifest *action.Manifes
 	writer.Flush()
 
 	// Finish Payload
-	if payload.HasContents() {
-		payloadName := payload.Name()
-		payload.Close()
+	if contents.HasContents() {
+		payloadName := contents.Name()
+		contents.Close()
 
 		// Copy Payload to zpkg file
 		payloadTmpFile, err := os.Open(payloadName)
@@ -0,0 +1,80 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"context"
+	"sort"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+	"github.com/fezz-io/zps/phase"
+	"github.com/fezz-io/zps/provider"
+)
+
+// alternativeOwner returns the candidate that owns a link, the selected package if it is
+// still registered, otherwise the highest priority with ties going to the package name.
+// Entries are left in that order of preference.
+func alternativeOwner(entries []*AlternativeEntry, selected string) *AlternativeEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Priority != entries[j].Priority {
+			return entries[i].Priority > entries[j].Priority
+		}
+
+		return entries[i].Pkg < entries[j].Pkg
+	})
+
+	for _, entry := range entries {
+		if selected != "" && entry.Pkg == selected {
+			return entry
+		}
+	}
+
+	return entries[0]
+}
+
+// relinkAlternative points a link at the target of its current owner, a selection naming a
+// package that is no longer registered is dropped so the link follows priority again
+func relinkAlternative(emitter *emission.Emitter, state *State, factory *provider.Factory, targetPath string, link string) error {
+	entries, err := state.Alternatives.Get(link)
+	if err != nil {
+		return err
+	}
+
+	selected, err := state.Alternatives.Selected(link)
+	if err != nil {
+		return err
+	}
+
+	owner := alternativeOwner(entries, selected)
+
+	if selected != "" && (owner == nil || owner.Pkg != selected) {
+		emitter.Emit("manager.warn", "alternative "+link+" selected "+selected+" is no longer installed, using automatic selection")
+
+		err = state.Alternatives.Auto(link)
+		if err != nil {
+			return err
+		}
+	}
+
+	if owner == nil {
+		return nil
+	}
+
+	ctx := context.WithValue(context.Background(), "options", &provider.Options{TargetPath: targetPath})
+	ctx = context.WithValue(ctx, "phase", phase.INSTALL)
+
+	return factory.Get(&action.Alternative{Link: owner.Link, Target: owner.Target, Priority: owner.Priority}).Realize(ctx)
+}
@@ -0,0 +1,63 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"testing"
+
+	"github.com/fezz-io/zps/action"
+)
+
+func TestAlternativeOwner(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates map[string]int
+		selected   string
+		expected   string
+	}{
+		{"none", map[string]int{}, "", ""},
+		{"single", map[string]int{"vim": 10}, "", "vim"},
+		{"highest priority", map[string]int{"vim": 10, "nano": 20, "ed": 5}, "", "nano"},
+		{"ties by name", map[string]int{"vim": 10, "nano": 10}, "", "nano"},
+		{"selected", map[string]int{"vim": 10, "nano": 20}, "vim", "vim"},
+		{"selected not registered", map[string]int{"vim": 10, "nano": 20}, "emacs", "nano"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var entries []*AlternativeEntry
+			for pkg, priority := range test.candidates {
+				entries = append(entries, NewAlternativeEntry(pkg, &action.Alternative{
+					Link:     "usr/bin/editor",
+					Target:   "usr/bin/" + pkg,
+					Priority: priority,
+				}))
+			}
+
+			owner := alternativeOwner(entries, test.selected)
+
+			if test.expected == "" {
+				if owner != nil {
+					t.Errorf("got %s, want no owner", owner.Pkg)
+				}
+				return
+			}
+
+			if owner == nil {
+				t.Fatalf("got no owner, want %s", test.expected)
+			}
+
+			if owner.Pkg != test.expected {
+				t.Errorf("got %s, want %s", owner.Pkg, test.expected)
+			}
+		})
+	}
+}
@@ -14,18 +14,19 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"hash"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
+// Cache is safe for concurrent use, packages are downloaded to a partial file and
+// only renamed into place once complete
 type Cache struct {
-	path   string
-	hasher hash.Hash
+	path string
 }
 
 func NewCache(path string) *Cache {
-	return &Cache{path, sha256.New()}
+	return &Cache{path}
 }
 
 func (c *Cache) Exists(name string) bool {
@@ -69,6 +70,61 @@ func (c *Cache) GetFile(name string) string {
 	return filepath.Join(c.path, name)
 }
 
+// GetPartial is the download target for a package file until it is committed
+func (c *Cache) GetPartial(name string) string {
+	return filepath.Join(c.path, name+".part")
+}
+
+// Valid reports whether a package file is cached and matches the size and checksum
+// from repository metadata, corrupt entries are evicted. Metadata published before
+// checksums were recorded carries neither, those entries are only checked for presence.
+func (c *Cache) Valid(name string, size int64, checksum string) bool {
+	if !c.Exists(name) {
+		return false
+	}
+
+	if verify(c.GetFile(name), size, checksum) != nil {
+		c.Evict(name)
+		return false
+	}
+
+	return true
+}
+
+// Resume returns the offset a download should continue from. Partials are only resumed
+// when the expected size is known, anything else is discarded and fetched again.
+func (c *Cache) Resume(name string, size int64) int64 {
+	info, err := os.Stat(c.GetPartial(name))
+	if err != nil {
+		return 0
+	}
+
+	if size <= 0 || info.Size() > size {
+		os.Remove(c.GetPartial(name))
+		return 0
+	}
+
+	return info.Size()
+}
+
+// Commit verifies a completed download and atomically moves it into place,
+// a partial that fails verification is removed
+func (c *Cache) Commit(name string, size int64, checksum string) error {
+	err := verify(c.GetPartial(name), size, checksum)
+	if err != nil {
+		os.Remove(c.GetPartial(name))
+		return err
+	}
+
+	return os.Rename(c.GetPartial(name), c.GetFile(name))
+}
+
+// Evict removes a package file and any partial download of it
+func (c *Cache) Evict(name string) {
+	os.Remove(c.GetFile(name))
+	os.Remove(c.GetPartial(name))
+}
+
 func (c *Cache) Clean() error {
 	pkgs, _ := filepath.Glob(filepath.Join(c.path, "*.zpkg"))
 
@@ -76,6 +132,12 @@ func (c *Cache) Clean() error {
 		os.Remove(f)
 	}
 
+	partials, _ := filepath.Glob(filepath.Join(c.path, "*.zpkg.part"))
+
+	for _, f := range partials {
+		os.Remove(f)
+	}
+
 	return nil
 }
 
@@ -100,8 +162,30 @@ func (c *Cache) Clear() error {
 	return nil
 }
 
+func verify(path string, size int64, checksum string) error {
+	if size <= 0 && checksum == "" {
+		return nil
+	}
+
+	actualSize, actualChecksum, err := FileChecksum(path)
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), ".part")
+
+	if size > 0 && actualSize != size {
+		return fmt.Errorf("size mismatch for %s: expected %d, got %d", name, size, actualSize)
+	}
+
+	if checksum != "" && actualChecksum != checksum {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", name, checksum, actualChecksum)
+	}
+
+	return nil
+}
+
 func (c *Cache) getId(id string) string {
-	c.hasher.Reset()
-	c.hasher.Write([]byte(id))
-	return hex.EncodeToString(c.hasher.Sum(nil))
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,152 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+const testZpkg = "pkg@1.0.0-linux-x86_64.zpkg"
+
+var testContent = []byte("zpkg content")
+
+func testChecksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCacheValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  []byte
+		size     int64
+		checksum string
+		valid    bool
+	}{
+		{"missing", nil, 12, testChecksum(testContent), false},
+		{"matching", testContent, 12, testChecksum(testContent), true},
+		{"no metadata", testContent, 0, "", true},
+		{"truncated", testContent[:4], 12, testChecksum(testContent), false},
+		{"corrupt", []byte("zpkg CONTENT"), 12, testChecksum(testContent), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := testCache(t)
+			defer os.RemoveAll(cache.path)
+
+			if test.content != nil {
+				if err := ioutil.WriteFile(cache.GetFile(testZpkg), test.content, 0640); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if valid := cache.Valid(testZpkg, test.size, test.checksum); valid != test.valid {
+				t.Errorf("got %v, want %v", valid, test.valid)
+			}
+
+			// Invalid entries are evicted so the next fetch starts over
+			if cache.Exists(testZpkg) != test.valid {
+				t.Errorf("exists: got %v, want %v", cache.Exists(testZpkg), test.valid)
+			}
+		})
+	}
+}
+
+func TestCacheResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial []byte
+		size    int64
+		offset  int64
+		kept    bool
+	}{
+		{"no partial", nil, 12, 0, false},
+		{"partial", testContent[:5], 12, 5, true},
+		{"complete", testContent, 12, 12, true},
+		{"unknown size", testContent[:5], 0, 0, false},
+		{"oversized", append(testContent, 'x'), 12, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := testCache(t)
+			defer os.RemoveAll(cache.path)
+
+			if test.partial != nil {
+				if err := ioutil.WriteFile(cache.GetPartial(testZpkg), test.partial, 0640); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if offset := cache.Resume(testZpkg, test.size); offset != test.offset {
+				t.Errorf("got %d, want %d", offset, test.offset)
+			}
+
+			_, err := os.Stat(cache.GetPartial(testZpkg))
+			if kept := err == nil; kept != test.kept {
+				t.Errorf("partial kept: got %v, want %v", kept, test.kept)
+			}
+		})
+	}
+}
+
+func TestCacheCommit(t *testing.T) {
+	tests := []struct {
+		name     string
+		partial  []byte
+		checksum string
+		fails    bool
+	}{
+		{"verified", testContent, testChecksum(testContent), false},
+		{"no checksum", testContent, "", false},
+		{"corrupt", []byte("zpkg CONTENT"), testChecksum(testContent), true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cache := testCache(t)
+			defer os.RemoveAll(cache.path)
+
+			if err := ioutil.WriteFile(cache.GetPartial(testZpkg), test.partial, 0640); err != nil {
+				t.Fatal(err)
+			}
+
+			err := cache.Commit(testZpkg, int64(len(test.partial)), test.checksum)
+			if (err != nil) != test.fails {
+				t.Errorf("error: got %v, want failure %v", err, test.fails)
+			}
+
+			if cache.Exists(testZpkg) == test.fails {
+				t.Errorf("committed: got %v, want %v", cache.Exists(testZpkg), !test.fails)
+			}
+
+			if _, err := os.Stat(cache.GetPartial(testZpkg)); !os.IsNotExist(err) {
+				t.Error("partial left behind")
+			}
+		})
+	}
+}
+
+func testCache(t *testing.T) *Cache {
+	t.Helper()
+
+	path, err := ioutil.TempDir("", "zpm-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewCache(path)
+}
@@ -2,10 +2,14 @@ package zpm
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/url"
+	"os"
 	"sort"
 	"strings"
 
@@ -35,6 +39,57 @@ func FilterPackagesByArch(osarch *zps.OsArch, zpkgs map[string]*zps.Pkg) ([]stri
 	return files, pkgs
 }
 
+// ManifestDigest identifies package content independently of signatures, as used by lockfiles
+func ManifestDigest(manifest *action.Manifest) string {
+	digest := sha256.Sum256([]byte(manifest.ToSigningJson()))
+
+	return hex.EncodeToString(digest[:])
+}
+
+// FileChecksum returns the size and sha256 of a file as recorded in repository metadata
+func FileChecksum(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+
+	size, err := io.Copy(hasher, file)
+	if err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// readPublishPkg loads a zpkg for publishing, its size and sha256 are recorded in metadata
+// so fetchers can verify downloads
+func readPublishPkg(file string) (*zps.Pkg, error) {
+	reader := zpkg.NewReader(file, "")
+
+	err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := zps.NewPkgFromManifest(reader.Manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	size, checksum, err := FileChecksum(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg.SetSize(size)
+	pkg.SetChecksum(checksum)
+
+	return pkg, nil
+}
+
 func PublisherFromUri(uri *url.URL) string {
 	parts := strings.Split(uri.Path, "/")
 
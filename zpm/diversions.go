@@ -0,0 +1,84 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"github.com/fezz-io/zps/action"
+)
+
+// Diversions indexes diversion entries by the diverted path
+type Diversions map[string]*DiversionEntry
+
+func NewDiversions(state *State) (Diversions, error) {
+	entries, err := state.Diversions.All()
+	if err != nil {
+		return nil, err
+	}
+
+	diversions := make(Diversions)
+	for _, entry := range entries {
+		diversions[entry.Path] = entry
+	}
+
+	return diversions, nil
+}
+
+// Apply returns a fs object as a package installs it, objects at a diverted path are moved to
+// the diversion unless the package owns it. Directories are never diverted.
+func (d Diversions) Apply(pkg string, fsObject action.Action) action.Action {
+	to, diverted := d.divert(pkg, fsObject.Key())
+
+	switch object := fsObject.(type) {
+	case *action.File:
+		if diverted {
+			file := *object
+			file.Path = to
+			return &file
+		}
+	case *action.SymLink:
+		if diverted {
+			symlink := *object
+			symlink.Path = to
+			return &symlink
+		}
+	case *action.Node:
+		if diverted {
+			node := *object
+			node.Path = to
+			return &node
+		}
+	case *action.HardLink:
+		// The file a hard link names may have been diverted on its own
+		target, targetDiverted := d.divert(pkg, object.Target)
+
+		if diverted || targetDiverted {
+			link := *object
+			if diverted {
+				link.Path = to
+			}
+			if targetDiverted {
+				link.Target = target
+			}
+			return &link
+		}
+	}
+
+	return fsObject
+}
+
+func (d Diversions) divert(pkg string, objectPath string) (string, bool) {
+	entry, ok := d[objectPath]
+	if !ok || entry.Pkg == pkg {
+		return "", false
+	}
+
+	return entry.To, true
+}
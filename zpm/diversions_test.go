@@ -0,0 +1,98 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/fezz-io/zps/action"
+)
+
+func TestDiversionsApply(t *testing.T) {
+	diversions := Diversions{
+		"etc/app.conf": {Path: "etc/app.conf", To: "etc/app.conf.dist", Pkg: "local"},
+		"usr/bin/tool": {Path: "usr/bin/tool", To: "usr/bin/tool.real", Pkg: "wrapper"},
+	}
+
+	tests := []struct {
+		name     string
+		pkg      string
+		object   action.Action
+		expected action.Action
+	}{
+		{
+			name:     "file",
+			pkg:      "app",
+			object:   &action.File{Path: "etc/app.conf", Mode: "0644"},
+			expected: &action.File{Path: "etc/app.conf.dist", Mode: "0644"},
+		},
+		{
+			name:     "file owned by the diverting package",
+			pkg:      "wrapper",
+			object:   &action.File{Path: "usr/bin/tool"},
+			expected: &action.File{Path: "usr/bin/tool"},
+		},
+		{
+			name:     "file not diverted",
+			pkg:      "app",
+			object:   &action.File{Path: "etc/other.conf"},
+			expected: &action.File{Path: "etc/other.conf"},
+		},
+		{
+			name:     "symlink",
+			pkg:      "tool",
+			object:   &action.SymLink{Path: "usr/bin/tool", Target: "tool-1.0"},
+			expected: &action.SymLink{Path: "usr/bin/tool.real", Target: "tool-1.0"},
+		},
+		{
+			name:     "node",
+			pkg:      "app",
+			object:   &action.Node{Path: "etc/app.conf"},
+			expected: &action.Node{Path: "etc/app.conf.dist"},
+		},
+		{
+			name:     "hard link",
+			pkg:      "tool",
+			object:   &action.HardLink{Path: "usr/bin/tool", Target: "usr/lib/tool"},
+			expected: &action.HardLink{Path: "usr/bin/tool.real", Target: "usr/lib/tool"},
+		},
+		{
+			name:     "hard link to a diverted file",
+			pkg:      "tool",
+			object:   &action.HardLink{Path: "usr/lib/tool", Target: "usr/bin/tool"},
+			expected: &action.HardLink{Path: "usr/lib/tool", Target: "usr/bin/tool.real"},
+		},
+		{
+			name:     "directory",
+			pkg:      "app",
+			object:   &action.Dir{Path: "etc/app.conf"},
+			expected: &action.Dir{Path: "etc/app.conf"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			original := reflect.ValueOf(test.object).Elem().Interface()
+
+			applied := diversions.Apply(test.pkg, test.object)
+
+			if !reflect.DeepEqual(applied, test.expected) {
+				t.Errorf("got %+v, want %+v", applied, test.expected)
+			}
+
+			// The manifest object is copied, never modified
+			if !reflect.DeepEqual(reflect.ValueOf(test.object).Elem().Interface(), original) {
+				t.Errorf("object modified: %+v", test.object)
+			}
+		})
+	}
+}
@@ -71,7 +71,7 @@ func NewABSFetcher(uri *url.URL, cache *Cache, security Security) *ABSFetcher {
 func (a *ABSFetcher) Refresh() error {
 	dst := a.cache.GetConfig(a.uri.String())
 
-	err := a.chunkedGet(path.Join(a.path, "config.db"), dst)
+	err := a.chunkedGet(path.Join(a.path, "config.db"), dst, 0)
 	if err != nil {
 		os.Remove(dst)
 
@@ -81,7 +81,7 @@ func (a *ABSFetcher) Refresh() error {
 	if a.security.Mode() != SecurityModeNone {
 		sdst := a.cache.GetConfigSig(a.uri.String())
 
-		err = a.chunkedGet(path.Join(a.path, "config.sig"), sdst)
+		err = a.chunkedGet(path.Join(a.path, "config.sig"), sdst, 0)
 		if err != nil {
 			os.Remove(sdst)
 
@@ -115,11 +115,21 @@ func (a *ABSFetcher) Fetch(pkg *zps.Pkg) error {
 	target := path.Join(a.path, osarch.String(), pkg.FileName())
 	cacheFile := a.cache.GetFile(pkg.FileName())
 
-	// Fetch package if not in cache
-	if !a.cache.Exists(cacheFile) {
-		err = a.chunkedGet(target, cacheFile)
+	// Fetch package if not in cache, resuming any partial download
+	if !a.cache.Valid(pkg.FileName(), pkg.Size(), pkg.Checksum()) {
+		offset := a.cache.Resume(pkg.FileName(), pkg.Size())
+
+		if pkg.Size() <= 0 || offset < pkg.Size() {
+			// Chunks are written in order so an interrupted transfer leaves a resumable partial
+			err = a.chunkedGet(target, a.cache.GetPartial(pkg.FileName()), offset)
+			if err != nil {
+				return errors.New(fmt.Sprintf("unable to download: %s", target))
+			}
+		}
+
+		err = a.cache.Commit(pkg.FileName(), pkg.Size(), pkg.Checksum())
 		if err != nil {
-			return errors.New(fmt.Sprintf("unable to download: %s", target))
+			return err
 		}
 	}
 
@@ -177,7 +187,7 @@ func (a *ABSFetcher) refresh(osarch *zps.OsArch) error {
 	target := path.Join(a.path, osarch.String(), "metadata.db")
 	dst := a.cache.GetMeta(osarch.String(), a.uri.String())
 
-	err = a.chunkedGet(target, dst)
+	err = a.chunkedGet(target, dst, 0)
 	if err != nil {
 		if !strings.Contains(err.Error(), "404") {
 			return errors.New(fmt.Sprintf("unable to download: %s", target))
@@ -191,7 +201,7 @@ func (a *ABSFetcher) refresh(osarch *zps.OsArch) error {
 		starget := path.Join(a.path, osarch.String(), "metadata.sig")
 		sdst := a.cache.GetMetaSig(osarch.String(), a.uri.String())
 
-		err = a.chunkedGet(starget, sdst)
+		err = a.chunkedGet(starget, sdst, 0)
 		if err != nil {
 			if !strings.Contains(err.Error(), "404") {
 				return errors.New(fmt.Sprintf("unable to download: %s", target))
@@ -215,8 +225,14 @@ func (a *ABSFetcher) refresh(osarch *zps.OsArch) error {
 	return nil
 }
 
-func (a *ABSFetcher) chunkedGet(source, dest string) error {
-	dst, err := os.OpenFile(dest, os.O_RDWR|os.O_CREATE, 0640)
+// chunkedGet downloads source to dest in 4MB ranges, appending from start when resuming
+func (a *ABSFetcher) chunkedGet(source, dest string, start int64) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if start == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	dst, err := os.OpenFile(dest, flags, 0640)
 	if err != nil {
 		return err
 	}
@@ -227,8 +243,8 @@ func (a *ABSFetcher) chunkedGet(source, dest string) error {
 		return err
 	}
 
-	offset := int64(0)
-	for offset <= props.ContentLength {
+	offset := start
+	for offset < props.ContentLength {
 		end := offset + 4*1024*1024
 		if end > props.ContentLength {
 			end = props.ContentLength
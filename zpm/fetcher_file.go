@@ -122,20 +122,35 @@ func (f *FileFetcher) Fetch(pkg *zps.Pkg) error {
 	defer lock.Unlock()
 
 	// Copy package if not in cache
-	if !f.cache.Exists(cacheFile) {
+	if !f.cache.Valid(packageFile, pkg.Size(), pkg.Checksum()) {
 		src, err := os.Open(repoFile)
 		if err != nil {
 			return err
 		}
 		defer src.Close()
 
-		dst, err := os.OpenFile(cacheFile, os.O_RDWR|os.O_CREATE, 0640)
+		_, err = src.Seek(f.cache.Resume(packageFile, pkg.Size()), io.SeekStart)
+		if err != nil {
+			return err
+		}
+
+		dst, err := os.OpenFile(f.cache.GetPartial(packageFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
 		if err != nil {
 			return err
 		}
-		defer dst.Close()
 
 		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return err
+		}
+
+		err = dst.Close()
+		if err != nil {
+			return err
+		}
+
+		err = f.cache.Commit(packageFile, pkg.Size(), pkg.Checksum())
+		if err != nil {
 			return err
 		}
 	}
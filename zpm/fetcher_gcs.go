@@ -133,42 +133,56 @@ func (g *GCSFetcher) Fetch(pkg *zps.Pkg) error {
 	target := path.Join(g.uri.Path, osarch.String(), pkg.FileName())
 	cacheFile := g.cache.GetFile(pkg.FileName())
 
-	// Fetch package if not in cache
-	if !g.cache.Exists(cacheFile) {
-		dst, err := os.OpenFile(cacheFile, os.O_RDWR|os.O_CREATE, 0640)
-		if err != nil {
-			return err
-		}
-		defer dst.Close()
+	// Fetch package if not in cache, resuming any partial download
+	if !g.cache.Valid(pkg.FileName(), pkg.Size(), pkg.Checksum()) {
+		partialFile := g.cache.GetPartial(pkg.FileName())
+		offset := g.cache.Resume(pkg.FileName(), pkg.Size())
+
+		if pkg.Size() <= 0 || offset < pkg.Size() {
+			dst, err := os.OpenFile(partialFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+			if err != nil {
+				return err
+			}
+			defer dst.Close()
 
-		ctx := context.Background()
-		client, err := storage.NewClient(ctx)
-		if err != nil {
-			return err
-		}
+			ctx := context.Background()
+			client, err := storage.NewClient(ctx)
+			if err != nil {
+				return err
+			}
 
-		cdCtx, cancel := context.WithTimeout(ctx, time.Second*600)
+			cdCtx, cancel := context.WithTimeout(ctx, time.Second*600)
 
-		cd, err := client.Bucket(g.uri.Host).Object(target).NewReader(cdCtx)
-		if err != nil {
-			cancel()
-			dst.Close()
-			os.Remove(cacheFile)
+			cd, err := client.Bucket(g.uri.Host).Object(target).NewRangeReader(cdCtx, offset, -1)
+			if err != nil {
+				cancel()
+				dst.Close()
+				os.Remove(partialFile)
 
-			return fmt.Errorf("unable to download: %s", target)
-		}
-		if _, err = io.Copy(dst, cd); err != nil {
+				return fmt.Errorf("unable to download: %s", target)
+			}
+			if _, err = io.Copy(dst, cd); err != nil {
+				cancel()
+				dst.Close()
+
+				return fmt.Errorf("download interrupted: %s", target)
+			}
+			if err := cd.Close(); err != nil {
+				cancel()
+				return fmt.Errorf("Reader.Close: %v", err)
+			}
 			cancel()
-			dst.Close()
-			os.Remove(cacheFile)
 
-			return fmt.Errorf("unable to download: %s", target)
+			err = dst.Close()
+			if err != nil {
+				return err
+			}
 		}
-		if err := cd.Close(); err != nil {
-			cancel()
-			return fmt.Errorf("Reader.Close: %v", err)
+
+		err = g.cache.Commit(pkg.FileName(), pkg.Size(), pkg.Checksum())
+		if err != nil {
+			return err
 		}
-		cancel()
 	}
 
 	// Validate pkg
@@ -13,6 +13,7 @@ package zpm
 import (
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path"
@@ -128,28 +129,68 @@ func (h *HttpsFetcher) Fetch(pkg *zps.Pkg) error {
 	user := fileUri.User.Username()
 	password, _ := fileUri.User.Password()
 
-	// Fetch package if not in cache
-	if !h.cache.Exists(cacheFile) {
-		resp, err := h.client.R().
-			SetBasicAuth(user, password).
-			SetOutput(cacheFile).
-			Get(fileUri.String())
+	// Fetch package if not in cache, resuming any partial download
+	if !h.cache.Valid(pkg.FileName(), pkg.Size(), pkg.Checksum()) {
+		partialFile := h.cache.GetPartial(pkg.FileName())
+		offset := h.cache.Resume(pkg.FileName(), pkg.Size())
 
-		if err != nil {
-			return errors.New(fmt.Sprintf("error connecting to: %s", h.uri.Host))
-		}
+		if pkg.Size() <= 0 || offset < pkg.Size() {
+			req := h.client.R().
+				SetBasicAuth(user, password).
+				SetDoNotParseResponse(true)
 
-		if resp.IsError() {
-			os.Remove(cacheFile)
+			if offset > 0 {
+				req.SetHeader("Range", fmt.Sprintf("bytes=%d-", offset))
+			}
 
-			switch resp.StatusCode() {
-			case 404:
-				return errors.New(fmt.Sprintf("not found: %s", fileUri.String()))
-			case 403:
-				return errors.New(fmt.Sprintf("access denied: %s", fileUri.String()))
-			default:
-				return errors.New(fmt.Sprintf("server error %d: %s", resp.StatusCode(), fileUri.String()))
+			resp, err := req.Get(fileUri.String())
+			if err != nil {
+				return errors.New(fmt.Sprintf("error connecting to: %s", h.uri.Host))
 			}
+
+			body := resp.RawBody()
+			defer body.Close()
+
+			if resp.IsError() {
+				os.Remove(partialFile)
+
+				switch resp.StatusCode() {
+				case 404:
+					return errors.New(fmt.Sprintf("not found: %s", fileUri.String()))
+				case 403:
+					return errors.New(fmt.Sprintf("access denied: %s", fileUri.String()))
+				default:
+					return errors.New(fmt.Sprintf("server error %d: %s", resp.StatusCode(), fileUri.String()))
+				}
+			}
+
+			// Servers that ignore the range send the whole file
+			flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			if resp.StatusCode() != 206 {
+				flags |= os.O_TRUNC
+			}
+
+			dst, err := os.OpenFile(partialFile, flags, 0640)
+			if err != nil {
+				return err
+			}
+
+			// Interrupted transfers keep the partial for the next attempt
+			if _, err = io.Copy(dst, body); err != nil {
+				dst.Close()
+
+				return errors.New(fmt.Sprintf("download interrupted: %s", fileUri.String()))
+			}
+
+			err = dst.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		err = h.cache.Commit(pkg.FileName(), pkg.Size(), pkg.Checksum())
+		if err != nil {
+			return err
 		}
 	}
 
@@ -14,6 +14,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path"
@@ -127,22 +128,52 @@ func (s *S3Fetcher) Fetch(pkg *zps.Pkg) error {
 	target := path.Join(s.uri.Path, osarch.String(), pkg.FileName())
 	cacheFile := s.cache.GetFile(pkg.FileName())
 
-	// Fetch package if not in cache
-	if !s.cache.Exists(cacheFile) {
-		dst, err := os.OpenFile(cacheFile, os.O_RDWR|os.O_CREATE, 0640)
-		if err != nil {
-			return err
-		}
-		defer dst.Close()
+	// Fetch package if not in cache, resuming any partial download
+	if !s.cache.Valid(pkg.FileName(), pkg.Size(), pkg.Checksum()) {
+		partialFile := s.cache.GetPartial(pkg.FileName())
+		offset := s.cache.Resume(pkg.FileName(), pkg.Size())
 
-		client := s3manager.NewDownloader(s.session)
+		if pkg.Size() <= 0 || offset < pkg.Size() {
+			dst, err := os.OpenFile(partialFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+			if err != nil {
+				return err
+			}
+			defer dst.Close()
 
-		_, err = client.Download(dst, &s3.GetObjectInput{
-			Bucket: aws.String(s.uri.Host),
-			Key:    aws.String(target),
-		})
+			input := &s3.GetObjectInput{
+				Bucket: aws.String(s.uri.Host),
+				Key:    aws.String(target),
+			}
+
+			if offset > 0 {
+				input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+			}
+
+			// Streamed in order so an interrupted transfer leaves a resumable partial
+			object, err := s3.New(s.session).GetObject(input)
+			if err != nil {
+				dst.Close()
+				os.Remove(partialFile)
+
+				return errors.New(fmt.Sprintf("unable to download: %s", target))
+			}
+			defer object.Body.Close()
+
+			if _, err = io.Copy(dst, object.Body); err != nil {
+				dst.Close()
+
+				return errors.New(fmt.Sprintf("download interrupted: %s", target))
+			}
+
+			err = dst.Close()
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.cache.Commit(pkg.FileName(), pkg.Size(), pkg.Checksum())
 		if err != nil {
-			return errors.New(fmt.Sprintf("unable to download: %s", target))
+			return err
 		}
 	}
 
@@ -0,0 +1,313 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Journal records the pre transaction state of every fs object a transaction touches
+// so that the image can be restored if the transaction fails part way through. Entries are
+// written to disk as they are staged so an interrupted transaction can be restored later.
+type Journal struct {
+	targetPath string
+	backupPath string
+
+	entries []*JournalEntry
+	index   map[string]bool
+}
+
+type JournalEntry struct {
+	Path   string
+	Backup string
+
+	Existed bool
+	IsDir   bool
+
+	Mode os.FileMode
+	Uid  int
+	Gid  int
+}
+
+func NewJournal(targetPath string, backupPath string) *Journal {
+	return &Journal{targetPath: targetPath, backupPath: backupPath, index: make(map[string]bool)}
+}
+
+// LoadJournal reads the entries staged by a previous run from the backup area
+func LoadJournal(targetPath string, backupPath string) (*Journal, error) {
+	journal := NewJournal(targetPath, backupPath)
+
+	file, err := os.Open(journal.entriesPath())
+	if os.IsNotExist(err) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry := &JournalEntry{}
+
+		// A partial last line is an entry that was never completely staged
+		err = json.Unmarshal(scanner.Bytes(), entry)
+		if err != nil {
+			break
+		}
+
+		journal.entries = append(journal.entries, entry)
+		journal.index[entry.Path] = true
+	}
+
+	return journal, scanner.Err()
+}
+
+func (j *Journal) Path() string {
+	return j.backupPath
+}
+
+// Stage preserves an existing file or symlink in the backup area, directories are only recorded.
+// The original is left in place so providers can inspect it, they must unlink rather than
+// rewrite a staged path. Only the first call for a given path is recorded since that reflects
+// the pre transaction state.
+func (j *Journal) Stage(objPath string) error {
+	if j.index[objPath] {
+		return nil
+	}
+
+	target := filepath.Join(j.targetPath, objPath)
+	entry := &JournalEntry{Path: objPath}
+
+	info, err := os.Lstat(target)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err == nil {
+		entry.Existed = true
+		entry.IsDir = info.IsDir()
+		entry.Mode = info.Mode()
+
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			entry.Uid = int(stat.Uid)
+			entry.Gid = int(stat.Gid)
+		}
+
+		if !entry.IsDir {
+			entry.Backup = filepath.Join(j.backupPath, "files", objPath)
+
+			err = os.MkdirAll(filepath.Dir(entry.Backup), 0750)
+			if err != nil {
+				return err
+			}
+
+			err = j.preserve(target, entry.Backup, info)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = j.write(entry)
+	if err != nil {
+		return err
+	}
+
+	j.entries = append(j.entries, entry)
+	j.index[objPath] = true
+
+	return nil
+}
+
+// Restore reverts every staged path in reverse order
+func (j *Journal) Restore() error {
+	for index := len(j.entries) - 1; index >= 0; index-- {
+		entry := j.entries[index]
+		target := filepath.Join(j.targetPath, entry.Path)
+
+		current, err := os.Lstat(target)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		exists := err == nil
+
+		if !entry.Existed {
+			if exists {
+				// Non empty directories are expected to fail here, they were not created by us
+				err = os.Remove(target)
+				if err != nil && !current.IsDir() {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if entry.IsDir {
+			if !exists {
+				err = os.Mkdir(target, entry.Mode.Perm())
+				if err != nil {
+					return err
+				}
+			}
+
+			os.Chmod(target, entry.Mode.Perm())
+			os.Lchown(target, entry.Uid, entry.Gid)
+
+			continue
+		}
+
+		// A missing backup was moved back by an earlier, interrupted restore
+		backup, err := os.Lstat(entry.Backup)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if exists && !current.IsDir() {
+			err = os.Remove(target)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = j.move(entry.Backup, target, backup)
+		if err != nil {
+			return err
+		}
+
+		os.Lchown(target, entry.Uid, entry.Gid)
+	}
+
+	return nil
+}
+
+// Clean removes the backup area once it is no longer required
+func (j *Journal) Clean() error {
+	return os.RemoveAll(j.backupPath)
+}
+
+// Entries are synced before the caller touches the staged path
+func (j *Journal) write(entry *JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(j.backupPath, 0750)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.entriesPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (j *Journal) entriesPath() string {
+	return filepath.Join(j.backupPath, "journal")
+}
+
+// Rename if possible, fall back to a copy when the backup area lives on another device
+func (j *Journal) move(src string, dst string, info os.FileInfo) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	err = j.copy(src, dst, info)
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(src)
+}
+
+// Hard link if possible, fall back to a copy when the backup area lives on another device
+func (j *Journal) preserve(src string, dst string, info os.FileInfo) error {
+	err := os.Link(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	return j.copy(src, dst, info)
+}
+
+func (j *Journal) copy(src string, dst string, info os.FileInfo) error {
+	if info.Mode()&os.ModeSymlink == os.ModeSymlink {
+		link, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+
+		return os.Symlink(link, dst)
+	}
+
+	// Special files have no content to copy, opening a fifo would block
+	if info.Mode()&os.ModeNamedPipe != 0 {
+		err := syscall.Mkfifo(dst, uint32(info.Mode().Perm()))
+		if err != nil {
+			return err
+		}
+
+		return os.Chmod(dst, info.Mode().Perm())
+	}
+
+	if info.Mode()&os.ModeDevice != 0 {
+		return fmt.Errorf("cannot copy device %s across file systems", src)
+	}
+
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	dest, err := os.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dest, source)
+	if err != nil {
+		dest.Close()
+		return err
+	}
+
+	err = dest.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, info.Mode().Perm())
+}
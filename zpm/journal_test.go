@@ -0,0 +1,299 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chuckpreslar/emission"
+)
+
+func TestJournalRestore(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(image string) error
+		change func(image string) error
+		check  func(image string) error
+	}{
+		{
+			name: "rewritten file",
+			setup: func(image string) error {
+				return ioutil.WriteFile(filepath.Join(image, "obj"), []byte("before"), 0640)
+			},
+			change: func(image string) error {
+				os.Remove(filepath.Join(image, "obj"))
+				return ioutil.WriteFile(filepath.Join(image, "obj"), []byte("after"), 0600)
+			},
+			check: func(image string) error {
+				return expectFile(filepath.Join(image, "obj"), "before", 0640)
+			},
+		},
+		{
+			name: "removed file",
+			setup: func(image string) error {
+				return ioutil.WriteFile(filepath.Join(image, "obj"), []byte("before"), 0644)
+			},
+			change: func(image string) error {
+				os.Remove(filepath.Join(image, "obj"))
+				return nil
+			},
+			check: func(image string) error {
+				return expectFile(filepath.Join(image, "obj"), "before", 0644)
+			},
+		},
+		{
+			name: "created file",
+			change: func(image string) error {
+				return ioutil.WriteFile(filepath.Join(image, "obj"), []byte("after"), 0644)
+			},
+			check: func(image string) error {
+				return expectMissing(filepath.Join(image, "obj"))
+			},
+		},
+		{
+			name: "replaced symlink",
+			setup: func(image string) error {
+				return os.Symlink("before", filepath.Join(image, "obj"))
+			},
+			change: func(image string) error {
+				os.Remove(filepath.Join(image, "obj"))
+				return os.Symlink("after", filepath.Join(image, "obj"))
+			},
+			check: func(image string) error {
+				return expectLink(filepath.Join(image, "obj"), "before")
+			},
+		},
+		{
+			name: "removed directory",
+			setup: func(image string) error {
+				return os.Mkdir(filepath.Join(image, "obj"), 0750)
+			},
+			change: func(image string) error {
+				return os.Remove(filepath.Join(image, "obj"))
+			},
+			check: func(image string) error {
+				return expectDir(filepath.Join(image, "obj"), 0750)
+			},
+		},
+		{
+			name: "created directory",
+			change: func(image string) error {
+				return os.Mkdir(filepath.Join(image, "obj"), 0755)
+			},
+			check: func(image string) error {
+				return expectMissing(filepath.Join(image, "obj"))
+			},
+		},
+		{
+			name: "created directory in use",
+			change: func(image string) error {
+				err := os.Mkdir(filepath.Join(image, "obj"), 0755)
+				if err != nil {
+					return err
+				}
+
+				return ioutil.WriteFile(filepath.Join(image, "obj", "other"), []byte("other"), 0644)
+			},
+			check: func(image string) error {
+				return expectFile(filepath.Join(image, "obj", "other"), "other", 0644)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			image, backup := testJournalDirs(t)
+			defer os.RemoveAll(filepath.Dir(image))
+
+			if test.setup != nil {
+				if err := test.setup(image); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			journal := NewJournal(image, backup)
+
+			if err := journal.Stage("obj"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := test.change(image); err != nil {
+				t.Fatal(err)
+			}
+
+			// Only the first stage of a path is recorded
+			if err := journal.Stage("obj"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := journal.Restore(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := test.check(image); err != nil {
+				t.Error(err)
+			}
+
+			if err := journal.Clean(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := expectMissing(backup); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestJournalRecover(t *testing.T) {
+	image, statePath := testJournalDirs(t)
+	defer os.RemoveAll(filepath.Dir(image))
+
+	err := os.Mkdir(statePath, 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(filepath.Join(image, "obj"), []byte("before"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := NewState(statePath)
+	emitter := emission.NewEmitter()
+
+	// Stage and change paths, then drop the transaction as an interrupted run would
+	tr := NewTransaction(emitter, image, nil, state)
+
+	err = tr.begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = state.Transactions.Put(tr.id.String(), "pkg@1.0.0", "install", "", &tr.date)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, objPath := range []string{"obj", "new"} {
+		if err := tr.journal.Stage(objPath); err != nil {
+			t.Fatal(err)
+		}
+
+		os.Remove(filepath.Join(image, objPath))
+
+		if err := ioutil.WriteFile(filepath.Join(image, objPath), []byte("after"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = RecoverTransactions(emitter, image, nil, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := expectFile(filepath.Join(image, "obj"), "before", 0644); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectMissing(filepath.Join(image, "new")); err != nil {
+		t.Error(err)
+	}
+
+	if err := expectMissing(tr.journal.Path()); err != nil {
+		t.Error(err)
+	}
+
+	entries, err := state.Transactions.Get(tr.id.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 || !entries[0].RolledBack {
+		t.Errorf("transaction entries not marked rolled back: %v", entries)
+	}
+}
+
+func testJournalDirs(t *testing.T) (string, string) {
+	t.Helper()
+
+	root, err := ioutil.TempDir("", "zpm-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	image := filepath.Join(root, "image")
+	if err := os.Mkdir(image, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return image, filepath.Join(root, "backup")
+}
+
+func expectFile(path string, content string, mode os.FileMode) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.Mode().IsRegular() || info.Mode().Perm() != mode {
+		return &os.PathError{Op: "mode " + info.Mode().String(), Path: path, Err: os.ErrInvalid}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if string(data) != content {
+		return &os.PathError{Op: "content " + string(data), Path: path, Err: os.ErrInvalid}
+	}
+
+	return nil
+}
+
+func expectLink(path string, target string) error {
+	link, err := os.Readlink(path)
+	if err != nil {
+		return err
+	}
+
+	if link != target {
+		return &os.PathError{Op: "link " + link, Path: path, Err: os.ErrInvalid}
+	}
+
+	return nil
+}
+
+func expectDir(path string, mode os.FileMode) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() || info.Mode().Perm() != mode {
+		return &os.PathError{Op: "mode " + info.Mode().String(), Path: path, Err: os.ErrInvalid}
+	}
+
+	return nil
+}
+
+func expectMissing(path string) error {
+	_, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return &os.PathError{Op: "exists", Path: path, Err: os.ErrExist}
+}
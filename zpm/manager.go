@@ -23,7 +23,11 @@ import (
 	"path"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/fezz-io/zps/provider"
 	"github.com/fezz-io/zps/sec"
@@ -31,6 +35,7 @@ import (
 	"github.com/fezz-io/zps/action"
 	"github.com/fezz-io/zps/phase"
 	"github.com/fezz-io/zps/zpkg"
+	"github.com/fezz-io/zps/zpkg/payload"
 	"github.com/fezz-io/zps/zps"
 
 	"github.com/chuckpreslar/emission"
@@ -38,6 +43,9 @@ import (
 	"github.com/nightlyone/lockfile"
 )
 
+// Upper bound on simultaneous package downloads
+const fetchConcurrency = 4
+
 type Manager struct {
 	*emission.Emitter
 
@@ -49,6 +57,9 @@ type Manager struct {
 
 	security Security
 
+	policy string
+	locked *config.LockFile
+
 	lock lockfile.Lockfile
 }
 
@@ -80,6 +91,131 @@ func NewManager(image string) (*Manager, error) {
 	return mgr, nil
 }
 
+// AlternativesAuto drops the selection for a link so its owner follows priority again
+func (m *Manager) AlternativesAuto(link string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	entries, err := m.state.Alternatives.Get(link)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return fmt.Errorf("no alternatives registered for %s", link)
+	}
+
+	err = m.state.Alternatives.Auto(link)
+	if err != nil {
+		return err
+	}
+
+	err = relinkAlternative(m.Emitter, m.state, m.factory(), m.config.CurrentImage.Path, link)
+	if err != nil {
+		return err
+	}
+
+	m.Emit("manager.info", fmt.Sprint("alternative ", link, " set to automatic"))
+	return nil
+}
+
+func (m *Manager) AlternativesList() ([]string, error) {
+	err := m.lockImage()
+	if err != nil {
+		return nil, err
+	}
+	defer m.lock.Unlock()
+
+	entries, err := m.state.Alternatives.All()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		m.Emitter.Emit("manager.warn", "No alternatives registered.")
+		return nil, nil
+	}
+
+	byLink := make(map[string][]*AlternativeEntry)
+	var links []string
+	for _, entry := range entries {
+		if _, ok := byLink[entry.Link]; !ok {
+			links = append(links, entry.Link)
+		}
+
+		byLink[entry.Link] = append(byLink[entry.Link], entry)
+	}
+	sort.Strings(links)
+
+	var output []string
+	for _, link := range links {
+		selected, err := m.state.Alternatives.Selected(link)
+		if err != nil {
+			return nil, err
+		}
+
+		mode := "auto"
+		if selected != "" {
+			mode = "manual"
+		}
+
+		candidates := byLink[link]
+		owner := alternativeOwner(candidates, selected)
+
+		for _, entry := range candidates {
+			status := ""
+			if entry == owner {
+				status = "[green]" + mode
+			}
+
+			output = append(output, strings.Join([]string{link, entry.Pkg, entry.Target, fmt.Sprint(entry.Priority), status}, "|"))
+		}
+	}
+
+	return output, nil
+}
+
+// AlternativesSet selects the package that owns a link regardless of priority
+func (m *Manager) AlternativesSet(link string, pkgName string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	entries, err := m.state.Alternatives.Get(link)
+	if err != nil {
+		return err
+	}
+
+	registered := false
+	for _, entry := range entries {
+		if entry.Pkg == pkgName {
+			registered = true
+		}
+	}
+
+	if !registered {
+		return fmt.Errorf("%s is not registered as an alternative for %s", pkgName, link)
+	}
+
+	err = m.state.Alternatives.Select(link, pkgName)
+	if err != nil {
+		return err
+	}
+
+	err = relinkAlternative(m.Emitter, m.state, m.factory(), m.config.CurrentImage.Path, link)
+	if err != nil {
+		return err
+	}
+
+	m.Emit("manager.info", fmt.Sprint("alternative ", link, " set to ", pkgName))
+	return nil
+}
+
 func (m *Manager) CacheClean() error {
 	err := m.cache.Clean()
 	if err != nil {
@@ -119,9 +255,9 @@ func (m *Manager) Channel(repo string, pkg string, channel string) error {
 }
 
 func (m *Manager) Configure(packages []string, profile string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -134,7 +270,7 @@ func (m *Manager) Configure(packages []string, profile string) error {
 	ctx := m.getContext(phase.CONFIGURE, options)
 	ctx = context.WithValue(ctx, "hclCtx", m.config.HclContext(profile))
 
-	factory := provider.DefaultFactory(m.Emitter)
+	factory := m.factory()
 
 	if len(packages) == 0 {
 		installed, err := m.state.Packages.All()
@@ -189,9 +325,9 @@ func (m *Manager) Configure(packages []string, profile string) error {
 }
 
 func (m *Manager) Contents(pkgName string) ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -204,8 +340,16 @@ func (m *Manager) Contents(pkgName string) ([]string, error) {
 		return nil, errors.New(fmt.Sprint(pkgName, " not installed"))
 	}
 
+	diversions, err := NewDiversions(m.state)
+	if err != nil {
+		return nil, err
+	}
+
+	// Objects are listed where they are installed
 	var contents action.Actions
-	contents = manifest.Section("Dir", "SymLink", "File")
+	for _, fsObject := range manifest.Section(action.FsSections...) {
+		contents = append(contents, diversions.Apply(pkgName, fsObject))
+	}
 
 	sort.Sort(contents)
 
@@ -217,10 +361,137 @@ func (m *Manager) Contents(pkgName string) ([]string, error) {
 	return output, nil
 }
 
+// DivertAdd diverts the copy of a path every package installs to a new location, a file
+// already installed at the path is moved there
+func (m *Manager) DivertAdd(divertPath string, to string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	divert := &action.Divert{Path: path.Clean(divertPath), To: path.Clean(to)}
+	if !divert.IsValid() {
+		return fmt.Errorf("invalid diversion of %s to %s", divertPath, to)
+	}
+
+	entry, err := m.state.Diversions.Get(divert.Path)
+	if err != nil {
+		return err
+	}
+
+	if entry != nil {
+		return fmt.Errorf("%s is already diverted to %s", entry.Path, entry.To)
+	}
+
+	fsEntries, err := m.state.Objects.Get(divert.To)
+	if err != nil {
+		return err
+	}
+
+	if len(fsEntries) != 0 {
+		return fmt.Errorf("%s is installed by %s", divert.To, fsEntries[0].Pkg)
+	}
+
+	options := &provider.Options{TargetPath: m.config.CurrentImage.Path}
+
+	err = m.factory().Get(divert).Realize(m.getContext(phase.INSTALL, options))
+	if err != nil {
+		return err
+	}
+
+	err = m.state.Diversions.Put(divert.Path, divert.To, "")
+	if err != nil {
+		return err
+	}
+
+	err = m.state.Objects.Divert(divert.Path, divert.To, "")
+	if err != nil {
+		return err
+	}
+
+	m.Emit("manager.info", fmt.Sprint("diverted ", divert.Path, " to ", divert.To))
+	return nil
+}
+
+func (m *Manager) DivertList() ([]string, error) {
+	err := m.lockImage()
+	if err != nil {
+		return nil, err
+	}
+	defer m.lock.Unlock()
+
+	entries, err := m.state.Diversions.All()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		m.Emitter.Emit("manager.warn", "No diversions found.")
+		return nil, nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	var output []string
+	for _, entry := range entries {
+		owner := entry.Pkg
+		if owner == "" {
+			owner = "[blue]local"
+		}
+
+		output = append(output, strings.Join([]string{entry.Path, entry.To, owner}, "|"))
+	}
+
+	return output, nil
+}
+
+// DivertRemove drops a local diversion and moves the diverted file back, the path must be free
+func (m *Manager) DivertRemove(divertPath string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	entry, err := m.state.Diversions.Get(path.Clean(divertPath))
+	if err != nil {
+		return err
+	}
+
+	if entry == nil {
+		return fmt.Errorf("%s is not diverted", divertPath)
+	}
+
+	if entry.Pkg != "" {
+		return fmt.Errorf("%s is diverted by package %s", entry.Path, entry.Pkg)
+	}
+
+	options := &provider.Options{TargetPath: m.config.CurrentImage.Path}
+
+	err = m.factory().Get(&action.Divert{Path: entry.Path, To: entry.To}).Realize(m.getContext(phase.REMOVE, options))
+	if err != nil {
+		return err
+	}
+
+	err = m.state.Diversions.Del(entry.Path)
+	if err != nil {
+		return err
+	}
+
+	err = m.state.Objects.Divert(entry.To, entry.Path, "")
+	if err != nil {
+		return err
+	}
+
+	m.Emit("manager.info", fmt.Sprint("removed diversion of ", entry.Path))
+	return nil
+}
+
 func (m *Manager) Fetch(args []string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -249,8 +520,7 @@ func (m *Manager) Fetch(args []string) error {
 		request.Install(req)
 	}
 
-	// TODO: configure policy
-	policy := zps.NewPolicy("updated")
+	policy := m.solverPolicy()
 
 	for _, job := range request.Jobs() {
 		pkg := policy.SelectRequest(pool.WhatProvides(job.Requirement()))
@@ -290,10 +560,144 @@ func (m *Manager) Fetch(args []string) error {
 	return err
 }
 
+func (m *Manager) Fix(packages []string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	manifests, err := m.manifests(packages)
+	if err != nil {
+		return err
+	}
+
+	pool, err := m.pool()
+	if err != nil {
+		return err
+	}
+
+	factory := m.factory()
+
+	for _, manifest := range manifests {
+		drifted, _, err := m.verify(manifest)
+		if err != nil {
+			return err
+		}
+
+		if len(drifted) == 0 {
+			continue
+		}
+
+		pkg, err := zps.NewPkgFromManifest(manifest)
+		if err != nil {
+			return err
+		}
+
+		// Fetch the installed version if it has been cleaned from the cache
+		if !m.cache.Exists(pkg.FileName()) {
+			req, err := zps.NewRequirementFromSimpleString(pkg.Id())
+			if err != nil {
+				return err
+			}
+
+			var candidate zps.Solvable
+			for _, solvable := range pool.WhatProvides(req) {
+				if solvable.Priority() > -1 && solvable.Name() == pkg.Name() {
+					candidate = solvable
+					break
+				}
+			}
+
+			if candidate == nil {
+				return fmt.Errorf("%s not found in cache or repos", pkg.Id())
+			}
+
+			err = m.fetch(pool, []*zps.Operation{zps.NewOperation(phase.INSTALL, candidate)})
+			if err != nil {
+				return err
+			}
+		}
+
+		reader := zpkg.NewReader(m.cache.GetFile(pkg.FileName()), "")
+
+		err = reader.Read()
+		if err != nil {
+			return err
+		}
+
+		options := &provider.Options{TargetPath: m.config.CurrentImage.Path}
+		ctx := m.getContext(phase.INSTALL, options)
+		ctx = context.WithValue(ctx, "payload", reader.Payload)
+
+		for _, fsObject := range drifted {
+			target, err := provider.SafeTarget(m.config.CurrentImage.Path, fsObject)
+			if err != nil {
+				reader.Close()
+				return err
+			}
+
+			// Config files keep local content, only mode and ownership are repaired
+			if file, ok := fsObject.(*action.File); ok && file.Config {
+				if info, err := os.Lstat(target); err == nil && info.Mode().IsRegular() {
+					err = m.fixMode(target, file.Mode, file.Owner, file.Group)
+					if err != nil {
+						reader.Close()
+						return err
+					}
+
+					m.Emit("manager.info", fmt.Sprintf("fixed %s %s", strings.ToUpper(fsObject.Type()), fsObject.Key()))
+					continue
+				}
+			}
+
+			// Existing directories are repaired in place, install does not reset their mode
+			if dir, ok := fsObject.(*action.Dir); ok {
+				if info, err := os.Lstat(target); err == nil && info.IsDir() {
+					err = m.fixMode(target, dir.Mode, dir.Owner, dir.Group)
+					if err != nil {
+						reader.Close()
+						return err
+					}
+
+					m.Emit("manager.info", fmt.Sprintf("fixed %s %s", strings.ToUpper(fsObject.Type()), fsObject.Key()))
+					continue
+				}
+			}
+
+			// Clear anything in the way, a directory may hold files of other packages or the admin
+			info, err := os.Lstat(target)
+			if err == nil && info.IsDir() && fsObject.Type() != "Dir" {
+				m.Emit("manager.error", fmt.Sprintf("%s %s: a directory is in the way, remove it and run fix again", strings.ToUpper(fsObject.Type()), fsObject.Key()))
+				continue
+			} else if err == nil && !info.IsDir() {
+				err = os.Remove(target)
+			}
+
+			if err != nil && !os.IsNotExist(err) {
+				reader.Close()
+				return err
+			}
+
+			err = factory.Get(fsObject).Realize(ctx)
+			if err != nil {
+				reader.Close()
+				return err
+			}
+
+			m.Emit("manager.info", fmt.Sprintf("fixed %s %s", strings.ToUpper(fsObject.Type()), fsObject.Key()))
+		}
+
+		reader.Close()
+	}
+
+	return nil
+}
+
 func (m *Manager) Freeze(args []string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -367,6 +771,14 @@ func (m *Manager) ImageInit(imageFilePath string, name string, imageOs string, a
 		return fmt.Errorf("unsupported arch %s", image.Arch)
 	}
 
+	if image.Policy != "" && zps.NewPolicy(image.Policy) == nil {
+		return fmt.Errorf("unsupported policy %s", image.Policy)
+	}
+
+	if image.ServiceManager != "" && !provider.DefaultFactory(m.Emitter).Provides("Service", image.ServiceManager) {
+		return fmt.Errorf("unsupported service manager %s", image.ServiceManager)
+	}
+
 	// Attempt to detect images path
 	imagesPath := os.Getenv("ZPS_IMAGES_PATH")
 	if imagesPath == "" {
@@ -442,6 +854,8 @@ func (m *Manager) ImageInit(imageFilePath string, name string, imageOs string, a
 	defaultImagePath := filepath.Join(m.config.ConfigPath(), config.ImagePath)
 
 	m.config.CurrentImage.Path = image.Path
+	m.config.CurrentImage.Policy = image.Policy
+	m.config.CurrentImage.ServiceManager = image.ServiceManager
 
 	// Create state db
 	os.MkdirAll(m.config.StatePath(), 0755)
@@ -512,32 +926,19 @@ func (m *Manager) ImageInit(imageFilePath string, name string, imageOs string, a
 		return err
 	}
 
-	// Install image packages
-	// TODO make all qualifiers work
+	// Install image packages, a lockfile if set replaces the Imagefile packages
 	request := zps.NewRequest()
 	for _, pkg := range image.Packages {
-		version := &zps.Version{}
-		req := zps.NewRequirement(pkg.Name, version)
-
-		if pkg.Version != "" {
-			err = version.Parse(pkg.Version)
-			if err != nil {
-				m.Emit("manager.warn", fmt.Sprintf("could not parse version for package: %s, skipping", pkg.Name))
-			}
-
-			if !version.Timestamp.IsZero() {
-				req.EXQ()
-			} else {
-				req.EQ()
-			}
-		} else {
-			req.ANY()
+		req, err := zps.NewRequirementFromExpression(pkg.Name, pkg.Operation, pkg.Version)
+		if err != nil {
+			m.Emit("manager.warn", fmt.Sprintf("could not parse version for package: %s, skipping", pkg.Name))
+			continue
 		}
 
 		request.Install(req)
 	}
 
-	if len(request.Jobs()) > 0 {
+	if len(request.Jobs()) > 0 || m.locked != nil {
 		err = m.Install(nil, request)
 		if err != nil {
 			return err
@@ -562,6 +963,9 @@ func (m *Manager) ImageInit(imageFilePath string, name string, imageOs string, a
 		Path: m.config.CurrentImage.Path,
 		Os:   m.config.CurrentImage.Os,
 		Arch: m.config.CurrentImage.Arch,
+
+		Policy:         m.config.CurrentImage.Policy,
+		ServiceManager: m.config.CurrentImage.ServiceManager,
 	}
 
 	if _, err := os.Stat(userImagePath); !os.IsNotExist(err) {
@@ -674,9 +1078,9 @@ func (m *Manager) ImageList() error {
 }
 
 func (m *Manager) Info(pkgName string) ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -700,6 +1104,7 @@ func (m *Manager) Info(pkgName string) ([]string, error) {
 		strings.Join([]string{"Version:", pkg.Version().Semver.String()}, "|"),
 		strings.Join([]string{"Timestamp:", pkg.Version().Timestamp.String()}, "|"),
 		strings.Join([]string{"Arch:", pkg.Arch()}, "|"),
+		strings.Join([]string{"Provides:", pkg.ProvidesString()}, "|"),
 		strings.Join([]string{"Summary:", pkg.Summary()}, "|"),
 		strings.Join([]string{"Description: ", pkg.Description()}, "|"),
 	}, err
@@ -714,9 +1119,9 @@ func (m *Manager) Install(args []string, request *zps.Request) error {
 		pprof.StartCPUProfile(f)
 		defer pprof.StopCPUProfile()
 	*/
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -731,7 +1136,12 @@ func (m *Manager) Install(args []string, request *zps.Request) error {
 		return errors.New("No repo metadata found. Please run zpm refresh.")
 	}
 
-	if request == nil {
+	if m.locked != nil {
+		request, err = m.lockedRequest(pool, reqs)
+		if err != nil {
+			return err
+		}
+	} else if request == nil {
 		request = zps.NewRequest()
 		for _, arg := range reqs {
 			req, err := zps.NewRequirementFromSimpleString(arg)
@@ -753,8 +1163,7 @@ func (m *Manager) Install(args []string, request *zps.Request) error {
 		}
 	}
 
-	// TODO: configure policy
-	solver := zps.NewSolver(pool, zps.NewPolicy("updated"))
+	solver := zps.NewSolver(pool, m.solverPolicy())
 
 	solution, err := solver.Solve(request)
 	if err != nil {
@@ -766,22 +1175,22 @@ func (m *Manager) Install(args []string, request *zps.Request) error {
 		return err
 	}
 
-	for _, op := range operations {
-		switch op.Operation {
-		case phase.INSTALL:
-			uri, _ := url.ParseRequestURI(pool.Location(op.Package.Location()).Uri)
-			fe := NewFetcher(uri, m.cache, m.security, m.config.CloudProvider())
+	if m.locked != nil {
+		err = m.checkLocked(operations)
+		if err != nil {
+			return err
+		}
+	}
 
-			m.Emitter.Emit("spin.start", fmt.Sprint("fetching: ", op.Package.Id()))
-			err = fe.Fetch(op.Package.(*zps.Pkg))
-			if err != nil {
-				m.Emitter.Emit("spin.error", fmt.Sprint("failed: ", op.Package.Id()))
-				return err
-			}
+	err = m.fetch(pool, operations)
+	if err != nil {
+		return err
+	}
 
-			m.Emitter.Emit("spin.success", fmt.Sprint("fetched: ", op.Package.Id()))
-		case phase.NOOP:
-			m.Emit("transaction.noop", fmt.Sprint("using: ", op.Package.Id()))
+	if m.locked != nil {
+		err = m.checkLockedChecksums(operations)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -790,6 +1199,7 @@ func (m *Manager) Install(args []string, request *zps.Request) error {
 	}
 
 	tr := NewTransaction(m.Emitter, m.config.CurrentImage.Path, m.cache, m.state)
+	m.serviceManager(tr.Factory())
 
 	err = tr.Realize(solution)
 
@@ -797,9 +1207,9 @@ func (m *Manager) Install(args []string, request *zps.Request) error {
 }
 
 func (m *Manager) List() ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -813,9 +1223,9 @@ func (m *Manager) List() ([]string, error) {
 
 		var line string
 		if pool.Frozen(pkg.Id()) {
-			line = "[blue]*|" + pkg.(*zps.Pkg).Columns()
+			line = "[blue]*|" + pkg.(*zps.Pkg).Columns() + "|" + pkg.(*zps.Pkg).ProvidesString()
 		} else {
-			line = "[white]~|" + pkg.(*zps.Pkg).Columns()
+			line = "[white]~|" + pkg.(*zps.Pkg).Columns() + "|" + pkg.(*zps.Pkg).ProvidesString()
 		}
 
 		output = append(output, line)
@@ -829,10 +1239,88 @@ func (m *Manager) List() ([]string, error) {
 	return output, nil
 }
 
+// Lock writes the exact id, publisher, repo and zpkg checksum of every installed package to a lockfile
+func (m *Manager) Lock(lockPath string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	pool, err := m.pool()
+	if err != nil {
+		return err
+	}
+
+	manifests, err := m.state.Packages.All()
+	if err != nil {
+		return err
+	}
+
+	lockFile := &config.LockFile{}
+
+	for _, manifest := range manifests {
+		pkg, err := zps.NewPkgFromManifest(manifest)
+		if err != nil {
+			return err
+		}
+
+		entry := &config.PkgConfig{
+			Name:      pkg.Name(),
+			Operation: "EXQ",
+			Version:   pkg.Version().String(),
+			Publisher: pkg.Publisher(),
+		}
+
+		for _, candidate := range pool.WhatProvides(zps.NewRequirement(pkg.Name(), pkg.Version()).EXQ()) {
+			if candidate.Priority() >= 0 && candidate.Id() == pkg.Id() {
+				entry.Repo = pool.Location(candidate.Location()).Uri
+				entry.Digest = candidate.(*zps.Pkg).Checksum()
+				break
+			}
+		}
+
+		if entry.Repo == "" {
+			m.Emit("manager.warn", fmt.Sprintf("%s is not available from any repo", pkg.Id()))
+		}
+
+		// Repos published before checksums were recorded leave the cached copy to go by
+		if entry.Digest == "" && m.cache.Exists(pkg.FileName()) {
+			_, entry.Digest, err = FileChecksum(m.cache.GetFile(pkg.FileName()))
+			if err != nil {
+				return err
+			}
+		}
+
+		if entry.Digest == "" {
+			m.Emit("manager.warn", fmt.Sprintf("%s has no checksum to lock", pkg.Id()))
+		}
+
+		lockFile.Packages = append(lockFile.Packages, entry)
+	}
+
+	sort.Slice(lockFile.Packages, func(i, j int) bool {
+		return lockFile.Packages[i].Name < lockFile.Packages[j].Name
+	})
+
+	if lockPath == "" {
+		lockPath = config.DefaultLockFile
+	}
+
+	err = ioutil.WriteFile(lockPath, lockFile.ToHclFile().Bytes(), 0640)
+	if err != nil {
+		return err
+	}
+
+	m.Emit("manager.info", fmt.Sprintf("locked %d packages: %s", len(lockFile.Packages), lockPath))
+
+	return nil
+}
+
 func (m *Manager) PkiKeyPairImport(certPath string, keyPath string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -888,9 +1376,9 @@ func (m *Manager) PkiKeyPairImport(certPath string, keyPath string) error {
 }
 
 func (m *Manager) PkiKeyPairList() ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -913,9 +1401,9 @@ func (m *Manager) PkiKeyPairList() ([]string, error) {
 }
 
 func (m *Manager) PkiKeyPairRemove(fingerprint string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -958,9 +1446,9 @@ func (m *Manager) PkiTrustFetch(uriString string) error {
 }
 
 func (m *Manager) PkiTrustImport(certPath string, typ string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -980,9 +1468,9 @@ func (m *Manager) PkiTrustImport(certPath string, typ string) error {
 }
 
 func (m *Manager) PkiTrustList() ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -1006,9 +1494,9 @@ func (m *Manager) PkiTrustList() ([]string, error) {
 }
 
 func (m *Manager) PkiTrustRemove(fingerprint string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -1028,9 +1516,9 @@ func (m *Manager) PkiTrustRemove(fingerprint string) error {
 }
 
 func (m *Manager) Plan(action string, args []string) (*zps.Solution, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -1066,8 +1554,7 @@ func (m *Manager) Plan(action string, args []string) (*zps.Solution, error) {
 		}
 	}
 
-	// TODO: configure policy
-	solver := zps.NewSolver(pool, zps.NewPolicy("updated"))
+	solver := zps.NewSolver(pool, m.solverPolicy())
 
 	solution, err := solver.Solve(request)
 	if err != nil {
@@ -1111,9 +1598,9 @@ func (m *Manager) Publish(repo string, pkgs ...string) error {
 }
 
 func (m *Manager) Refresh() error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -1141,9 +1628,9 @@ func (m *Manager) Refresh() error {
 }
 
 func (m *Manager) Remove(args []string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -1166,8 +1653,7 @@ func (m *Manager) Remove(args []string) error {
 		request.Remove(req)
 	}
 
-	// TODO: configure policy
-	solver := zps.NewSolver(pool, zps.NewPolicy("updated"))
+	solver := zps.NewSolver(pool, m.solverPolicy())
 
 	solution, err := solver.Solve(request)
 	if err != nil {
@@ -1175,6 +1661,7 @@ func (m *Manager) Remove(args []string) error {
 	}
 
 	tr := NewTransaction(m.Emitter, m.config.CurrentImage.Path, m.cache, m.state)
+	m.serviceManager(tr.Factory())
 
 	err = tr.Realize(solution)
 
@@ -1198,9 +1685,9 @@ func (m *Manager) RepoInit(name string) error {
 }
 
 func (m *Manager) RepoUnlock(name string, removeEtag bool) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -1223,9 +1710,9 @@ func (m *Manager) RepoUnlock(name string, removeEtag bool) error {
 }
 
 func (m *Manager) RepoContents(name string) ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -1282,9 +1769,9 @@ func (m *Manager) RepoContents(name string) ([]string, error) {
 }
 
 func (m *Manager) RepoList() ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -1324,9 +1811,9 @@ func (m *Manager) RepoUpdate(name string) error {
 }
 
 func (m *Manager) Thaw(args []string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -1353,10 +1840,33 @@ func (m *Manager) Thaw(args []string) error {
 	return nil
 }
 
+// SetLocked restricts installs to the exact packages recorded in a lockfile
+func (m *Manager) SetLocked(lockPath string) error {
+	lockFile := &config.LockFile{}
+
+	err := lockFile.Load(lockPath)
+	if err != nil {
+		return err
+	}
+
+	m.locked = lockFile
+	return nil
+}
+
+// SetPolicy overrides the image solver policy for subsequent operations
+func (m *Manager) SetPolicy(name string) error {
+	if name != "" && zps.NewPolicy(name) == nil {
+		return fmt.Errorf("unsupported policy %s", name)
+	}
+
+	m.policy = name
+	return nil
+}
+
 func (m *Manager) Status(query string) (string, []string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return "", nil, errors.New("zpm: locked by another process")
+		return "", nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -1379,12 +1889,12 @@ func (m *Manager) Status(query string) (string, []string, error) {
 		var line string
 		if pool.Frozen(pkg.Id()) && pkg.Location() == 0 {
 			status = "Frozen"
-			line = "[blue]*|" + pkg.(*zps.Pkg).Columns()
+			line = "[blue]*|" + pkg.(*zps.Pkg).Columns() + "|" + pkg.(*zps.Pkg).ProvidesString()
 		} else if pkg.Priority() == -1 {
 			status = "Installed"
-			line = "[yellow]~|" + pkg.(*zps.Pkg).Columns()
+			line = "[yellow]~|" + pkg.(*zps.Pkg).Columns() + "|" + pkg.(*zps.Pkg).ProvidesString()
 		} else {
-			line = "[white]-|" + pkg.(*zps.Pkg).Columns()
+			line = "[white]-|" + pkg.(*zps.Pkg).Columns() + "|" + pkg.(*zps.Pkg).ProvidesString()
 		}
 		packages = append(packages, line)
 	}
@@ -1402,7 +1912,7 @@ func (m *Manager) Tpl(tplPath string, profile string) error {
 	ctx := m.getContext(phase.CONFIGURE, options)
 	ctx = context.WithValue(ctx, "hclCtx", m.config.HclContext(profile))
 
-	factory := provider.DefaultFactory(m.Emitter)
+	factory := m.factory()
 
 	tpl := &action.Template{
 		Name:   "",
@@ -1417,9 +1927,9 @@ func (m *Manager) Tpl(tplPath string, profile string) error {
 }
 
 func (m *Manager) TransActionList() ([]string, error) {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return nil, errors.New("zpm: locked by another process")
+		return nil, err
 	}
 	defer m.lock.Unlock()
 
@@ -1435,7 +1945,16 @@ func (m *Manager) TransActionList() ([]string, error) {
 			if index != 0 {
 				output = append(output, "")
 			}
-			output = append(output, strings.Join([]string{"[white]" + t.Id, t.Date.Format("Mon Jan 2 15:04:05 MST 2006")}, "|"))
+			header := []string{"[white]" + t.Id, t.Date.Format("Mon Jan 2 15:04:05 MST 2006")}
+			if t.Reverts != "" {
+				header = append(header, "[blue]reverts "+t.Reverts)
+			}
+
+			if t.RolledBack {
+				header = append(header, "[yellow]rolled back")
+			}
+
+			output = append(output, strings.Join(header, "|"))
 			seen[t.Id] = true
 		}
 
@@ -1459,10 +1978,168 @@ func (m *Manager) TransActionList() ([]string, error) {
 	return output, nil
 }
 
+func (m *Manager) TransactionRollback(id string) error {
+	err := m.lockImage()
+	if err != nil {
+		return err
+	}
+	defer m.lock.Unlock()
+
+	entries, err := m.state.Transactions.Get(id)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return fmt.Errorf("transaction not found: %s", id)
+	}
+
+	installed := make(map[string]string)
+	removed := make(map[string]string)
+	var names []string
+
+	for _, entry := range entries {
+		if entry.RolledBack {
+			return fmt.Errorf("transaction %s was already rolled back", id)
+		}
+
+		req, err := zps.NewRequirementFromSimpleString(entry.PkgId)
+		if err != nil {
+			return err
+		}
+
+		if installed[req.Name] == "" && removed[req.Name] == "" {
+			names = append(names, req.Name)
+		}
+
+		switch entry.Operation {
+		case phase.INSTALL:
+			installed[req.Name] = entry.PkgId
+		case phase.REMOVE:
+			removed[req.Name] = entry.PkgId
+		}
+	}
+
+	// Prefer cached copies of removed packages, they may have been pruned from the repos
+	var files []string
+	osArches := zps.ExpandOsArch(&zps.OsArch{Os: m.config.CurrentImage.Os, Arch: m.config.CurrentImage.Arch})
+	for _, name := range names {
+		if removed[name] == "" {
+			continue
+		}
+
+		for _, osArch := range osArches {
+			fileName := fmt.Sprintf("%s-%s.zpkg", removed[name], osArch.String())
+			if m.cache.Exists(fileName) {
+				files = append(files, m.cache.GetFile(fileName))
+			}
+		}
+	}
+
+	pool, err := m.pool(files...)
+	if err != nil {
+		return err
+	}
+
+	// Installed matches packages that merely provide a name, the image is checked by package name
+	image := make(map[string]zps.Solvable)
+	for _, solvable := range pool.Image() {
+		image[solvable.Name()] = solvable
+	}
+
+	request := zps.NewRequest()
+	for _, name := range names {
+		// The image must still reflect the outcome of the transaction
+		current := image[name]
+		if (current == nil && installed[name] != "") || (current != nil && current.Id() != installed[name]) {
+			return fmt.Errorf("%s has changed since transaction %s", name, id)
+		}
+
+		if removed[name] != "" {
+			req, err := zps.NewRequirementFromSimpleString(removed[name])
+			if err != nil {
+				return err
+			}
+
+			if len(pool.WhatProvides(req)) == 0 {
+				return fmt.Errorf("%s not found in cache or repos", removed[name])
+			}
+
+			request.Install(req)
+		} else {
+			req, err := zps.NewRequirementFromSimpleString(installed[name])
+			if err != nil {
+				return err
+			}
+
+			request.Remove(req)
+		}
+	}
+
+	solver := zps.NewSolver(pool, m.solverPolicy())
+
+	solution, err := solver.Solve(request)
+	if err != nil {
+		return err
+	}
+
+	operations, err := solution.Graph()
+	if err != nil {
+		return err
+	}
+
+	err = m.fetch(pool, operations)
+	if err != nil {
+		return err
+	}
+
+	if solution.Noop() {
+		return nil
+	}
+
+	tr := NewTransaction(m.Emitter, m.config.CurrentImage.Path, m.cache, m.state).Reverts(id)
+	m.serviceManager(tr.Factory())
+
+	return tr.Realize(solution)
+}
+
+// Verify only reads the state db and the image, it does not take the manager lock
+func (m *Manager) Verify(packages []string) ([]string, error) {
+	manifests, err := m.manifests(packages)
+	if err != nil {
+		return nil, err
+	}
+
+	var output []string
+	for _, manifest := range manifests {
+		drifted, reasons, err := m.verify(manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(drifted) == 0 {
+			m.Emit("manager.info", fmt.Sprint("verified ", manifest.Zpkg.Name))
+			continue
+		}
+
+		for index, fsObject := range drifted {
+			output = append(output, strings.Join([]string{
+				"[red]x",
+				manifest.Zpkg.Name,
+				strings.ToUpper(fsObject.Type()),
+				fsObject.Key(),
+				reasons[index],
+			}, "|"))
+		}
+	}
+
+	return output, nil
+}
+
 func (m *Manager) Update(reqs []string) error {
-	err := m.lock.TryLock()
+	err := m.lockImage()
 	if err != nil {
-		return errors.New("zpm: locked by another process")
+		return err
 	}
 	defer m.lock.Unlock()
 
@@ -1500,8 +2177,7 @@ func (m *Manager) Update(reqs []string) error {
 		request.Install(req)
 	}
 
-	// Updates require the update policy
-	solver := zps.NewSolver(pool, zps.NewPolicy("updated"))
+	solver := zps.NewSolver(pool, m.solverPolicy())
 
 	solution, err := solver.Solve(request)
 	if err != nil {
@@ -1513,20 +2189,9 @@ func (m *Manager) Update(reqs []string) error {
 		return err
 	}
 
-	for _, op := range operations {
-		switch op.Operation {
-		case phase.INSTALL:
-			uri, _ := url.ParseRequestURI(pool.Location(op.Package.Location()).Uri)
-			fe := NewFetcher(uri, m.cache, m.security, m.config.CloudProvider())
-			err = fe.Fetch(op.Package.(*zps.Pkg))
-			if err != nil {
-				return err
-			}
-
-			m.Emitter.Emit("manager.fetch", fmt.Sprint("fetching: ", op.Package.Id()))
-		case phase.NOOP:
-			m.Emit("transaction.noop", fmt.Sprint("using: ", op.Package.Id()))
-		}
+	err = m.fetch(pool, operations)
+	if err != nil {
+		return err
 	}
 
 	if solution.Noop() {
@@ -1534,13 +2199,29 @@ func (m *Manager) Update(reqs []string) error {
 	}
 
 	tr := NewTransaction(m.Emitter, m.config.CurrentImage.Path, m.cache, m.state)
+	m.serviceManager(tr.Factory())
 
 	err = tr.Realize(solution)
 
 	return err
 }
 
-func (m *Manager) ZpkgBuild(zpfPath string, targetPath string, workPath string, outputPath string, restrict bool, secure bool) error {
+func (m *Manager) ZpkgBuild(zpfPath string, targetPath string, workPath string, outputPath string, restrict bool, secure bool, compression string, level int, reproducible bool, sourceDateEpoch string, elfRequirements bool, acls bool) error {
+	compressionId, err := payload.CompressionId(compression)
+	if err != nil {
+		return err
+	}
+
+	// SOURCE_DATE_EPOCH is only honored when asked for, it may be left set in the environment
+	// by unrelated tooling. An explicit source date always enables a reproducible build.
+	if reproducible && sourceDateEpoch == "" {
+		sourceDateEpoch = os.Getenv("SOURCE_DATE_EPOCH")
+	}
+
+	if reproducible && sourceDateEpoch == "" {
+		return errors.New("reproducible builds require SOURCE_DATE_EPOCH or --source-date-epoch")
+	}
+
 	builder := zpkg.NewBuilder()
 
 	builder.Emitter = m.Emitter
@@ -1548,7 +2229,17 @@ func (m *Manager) ZpkgBuild(zpfPath string, targetPath string, workPath string,
 	builder.ZpfPath(zpfPath).
 		TargetPath(targetPath).WorkPath(workPath).
 		OutputPath(outputPath).Restrict(restrict).
-		Secure(secure)
+		Secure(secure).Compression(compressionId, level).
+		ElfRequirements(elfRequirements).Acls(acls)
+
+	if sourceDateEpoch != "" {
+		epoch, err := strconv.ParseInt(sourceDateEpoch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid source date epoch: %s", sourceDateEpoch)
+		}
+
+		builder.SourceDate(time.Unix(epoch, 0))
+	}
 
 	filename, manifest, err := builder.Build()
 	if err != nil {
@@ -1562,23 +2253,32 @@ func (m *Manager) ZpkgBuild(zpfPath string, targetPath string, workPath string,
 
 	if kp == nil {
 		m.Emitter.Emit("manager.warn", fmt.Sprintf("No keypair found for publisher %s, not signing.", manifest.Zpkg.Publisher))
+	} else {
+		signer := zpkg.NewSigner(filename, workPath)
 
-		return err
-	}
+		rsaKey, err := kp.RSAKey()
+		if err != nil {
+			return err
+		}
 
-	signer := zpkg.NewSigner(filename, workPath)
+		err = signer.Sign(kp.Fingerprint, rsaKey)
+		if err != nil {
+			return err
+		}
 
-	rsaKey, err := kp.RSAKey()
+		m.Emitter.Emit("manager.info", fmt.Sprintf("Signed with keypair: %s", kp.Subject))
+	}
+
+	// Builders compare these to confirm identical artifacts, the content digest excludes signatures
+	_, checksum, err := FileChecksum(filename)
 	if err != nil {
 		return err
 	}
 
-	err = signer.Sign(kp.Fingerprint, rsaKey)
-	if err == nil {
-		m.Emitter.Emit("manager.info", fmt.Sprintf("Signed with keypair: %s", kp.Subject))
-	}
+	m.Emitter.Emit("manager.info", fmt.Sprintf("Package digest: sha256:%s", checksum))
+	m.Emitter.Emit("manager.info", fmt.Sprintf("Content digest: sha256:%s", ManifestDigest(manifest)))
 
-	return err
+	return nil
 }
 
 // TODO consider merging with Contents command via file path sniffing
@@ -1590,7 +2290,7 @@ func (m *Manager) ZpkgContents(path string) ([]string, error) {
 		return nil, err
 	}
 
-	contents := reader.Manifest.Section("Dir", "SymLink", "File")
+	contents := reader.Manifest.Section(action.FsSections...)
 
 	sort.Sort(contents)
 
@@ -1614,10 +2314,9 @@ func (m *Manager) ZpkgExtract(filepath string, target string) error {
 	ctx := m.getContext(phase.INSTALL, options)
 	ctx = context.WithValue(ctx, "payload", reader.Payload)
 
-	contents := reader.Manifest.Section("Dir", "SymLink", "File")
-	sort.Sort(contents)
+	contents := reader.Manifest.FsObjects()
 
-	factory := provider.DefaultFactory(m.Emitter)
+	factory := m.factory()
 
 	for _, fsObject := range contents {
 		m.Emit("manager.info", fmt.Sprintf("Extracted => %s %s", strings.ToUpper(fsObject.Type()), path.Join(target, fsObject.Key())))
@@ -1631,6 +2330,60 @@ func (m *Manager) ZpkgExtract(filepath string, target string) error {
 	return nil
 }
 
+// ZpkgImport converts a deb, rpm or tarball into a zpkg via a generated Zpkgfile,
+// name and version override values taken from the archive
+func (m *Manager) ZpkgImport(archivePath string, publisher string, name string, version string, workPath string, outputPath string, compression string, level int) error {
+	tmpDir, err := ioutil.TempDir(workPath, "import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	protoPath := filepath.Join(tmpDir, zpkg.DefaultTargetDir)
+
+	imp, err := zpkg.ImportArchive(archivePath, protoPath)
+	if err != nil {
+		return err
+	}
+
+	imp.Manifest.Zpkg.Publisher = publisher
+
+	if name != "" {
+		imp.Manifest.Zpkg.Name = name
+	}
+
+	if version != "" {
+		imp.Manifest.Zpkg.Version = version
+	}
+
+	if imp.Manifest.Zpkg.Version == "" {
+		return fmt.Errorf("unable to determine version of %s, use --version", filepath.Base(archivePath))
+	}
+
+	for _, warning := range imp.Warnings {
+		m.Emitter.Emit("manager.warn", warning)
+	}
+
+	for _, unsupported := range imp.Unsupported {
+		m.Emitter.Emit("manager.warn", fmt.Sprintf("Unsupported %s not imported", unsupported))
+	}
+
+	zpfPath := filepath.Join(tmpDir, zpkg.DefaultZpfPath)
+
+	err = ioutil.WriteFile(zpfPath, imp.ToZpkgfile().Bytes(), 0640)
+	if err != nil {
+		return err
+	}
+
+	// The source package build time keeps repeated imports of the same archive identical
+	var sourceDateEpoch string
+	if !imp.BuildTime.IsZero() {
+		sourceDateEpoch = strconv.FormatInt(imp.BuildTime.Unix(), 10)
+	}
+
+	return m.ZpkgBuild(zpfPath, protoPath, workPath, outputPath, false, true, compression, level, false, sourceDateEpoch, false, false)
+}
+
 // TODO consider merging with Info command utilizing file path sniffing
 func (m *Manager) ZpkgInfo(path string) (string, error) {
 	reader := zpkg.NewReader(path, "")
@@ -1652,6 +2405,8 @@ func (m *Manager) ZpkgInfo(path string) (string, error) {
 		fmt.Sprint("Timestamp: ", pkg.Version().Timestamp, "\n") +
 		fmt.Sprint("OS: ", pkg.Os(), "\n") +
 		fmt.Sprint("Arch: ", pkg.Arch(), "\n") +
+		fmt.Sprint("Provides: ", pkg.ProvidesString(), "\n") +
+		fmt.Sprint("Compression: ", payload.CompressionName(reader.Header.Compression), "\n") +
 		fmt.Sprint("Summary: ", pkg.Summary(), "\n") +
 		fmt.Sprint("Description: ", pkg.Description(), "\n")
 
@@ -1716,6 +2471,22 @@ func (m *Manager) ZpkgValidate(path string) error {
 	return ValidateZpkg(m.Emitter, m.security, path, false)
 }
 
+// lockImage takes the image lock and restores any transaction an interrupted run left unfinished
+func (m *Manager) lockImage() error {
+	err := m.lock.TryLock()
+	if err != nil {
+		return errors.New("zpm: locked by another process")
+	}
+
+	err = RecoverTransactions(m.Emitter, m.config.CurrentImage.Path, m.cache, m.state)
+	if err != nil {
+		m.lock.Unlock()
+		return err
+	}
+
+	return nil
+}
+
 func (m *Manager) image() (*zps.Repo, error) {
 	packages, err := m.state.Packages.All()
 	if err != nil {
@@ -1734,6 +2505,104 @@ func (m *Manager) image() (*zps.Repo, error) {
 	return image, nil
 }
 
+// fetch downloads install operations concurrently, bounded by fetchConcurrency
+func (m *Manager) fetch(pool *zps.Pool, operations []*zps.Operation) error {
+	var batches [][]*zps.Operation
+	var total int
+	fileRepos := make(map[int]int)
+
+	for _, op := range operations {
+		switch op.Operation {
+		case phase.INSTALL:
+			total++
+
+			// File repos take a repo lock for every fetch, keep each of them on a single worker
+			location := op.Package.Location()
+			if strings.HasPrefix(pool.Location(location).Uri, "file:") {
+				if index, ok := fileRepos[location]; ok {
+					batches[index] = append(batches[index], op)
+					continue
+				}
+
+				fileRepos[location] = len(batches)
+			}
+
+			batches = append(batches, []*zps.Operation{op})
+		case phase.NOOP:
+			m.Emit("transaction.noop", fmt.Sprint("using: ", op.Package.Id()))
+		}
+	}
+
+	if len(batches) == 0 {
+		return nil
+	}
+
+	workers := fetchConcurrency
+	if len(batches) < workers {
+		workers = len(batches)
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var fetchErr error
+	var fetched int32
+
+	queue := make(chan []*zps.Operation)
+	failed := make(chan struct{})
+
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for batch := range queue {
+				for _, op := range batch {
+					select {
+					case <-failed:
+						return
+					default:
+					}
+
+					uri, _ := url.ParseRequestURI(pool.Location(op.Package.Location()).Uri)
+					fe := NewFetcher(uri, m.cache, m.security, m.config.CloudProvider())
+
+					m.Emit("manager.fetch", fmt.Sprint("fetching: ", op.Package.Id()))
+
+					err := fe.Fetch(op.Package.(*zps.Pkg))
+					if err != nil {
+						m.Emit("manager.error", fmt.Sprint("failed: ", op.Package.Id()))
+
+						once.Do(func() {
+							fetchErr = err
+							close(failed)
+						})
+
+						return
+					}
+
+					m.Emit("manager.fetch", fmt.Sprintf("fetched: %s (%d/%d)", op.Package.Id(), atomic.AddInt32(&fetched, 1), total))
+				}
+			}
+		}()
+	}
+
+	// Stop handing out work after the first failure
+feed:
+	for _, batch := range batches {
+		select {
+		case queue <- batch:
+		case <-failed:
+			break feed
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	return fetchErr
+}
+
 func (m *Manager) fileRepos(files ...string) ([]*zps.Repo, error) {
 	var repos []*zps.Repo
 	index := make(map[string]*zps.Repo)
@@ -1763,6 +2632,54 @@ func (m *Manager) fileRepos(files ...string) ([]*zps.Repo, error) {
 	return repos, nil
 }
 
+// Returns installed manifests for the named packages, or all installed packages if none are named
+func (m *Manager) manifests(packages []string) ([]*action.Manifest, error) {
+	if len(packages) == 0 {
+		return m.state.Packages.All()
+	}
+
+	var manifests []*action.Manifest
+	for _, name := range packages {
+		manifest, err := m.state.Packages.Get(name)
+		if err != nil {
+			return nil, err
+		}
+
+		if manifest == nil {
+			return nil, errors.New(fmt.Sprint(name, " not installed"))
+		}
+
+		manifests = append(manifests, manifest)
+	}
+
+	return manifests, nil
+}
+
+// factory returns the provider factory with the providers configured for the image selected
+func (m *Manager) factory() *provider.Factory {
+	return m.serviceManager(provider.DefaultFactory(m.Emitter))
+}
+
+// serviceManager selects the service manager backend configured for the image, only the
+// image at / defaults to the host service manager, other images do not manage services
+func (m *Manager) serviceManager(factory *provider.Factory) *provider.Factory {
+	name := m.config.CurrentImage.ServiceManager
+	if name == "" {
+		if filepath.Clean(m.config.CurrentImage.Path) == "/" {
+			return factory
+		}
+
+		name = "none"
+	}
+
+	if !factory.Provides("Service", name) {
+		m.Emit("manager.warn", fmt.Sprintf("unsupported service manager %s, using the default", name))
+		return factory
+	}
+
+	return factory.Use("Service", name)
+}
+
 func (m *Manager) getContext(phase string, options *provider.Options) context.Context {
 	ctx := context.WithValue(context.Background(), "phase", phase)
 	ctx = context.WithValue(ctx, "options", options)
@@ -1841,6 +2758,57 @@ func (m *Manager) pool(files ...string) (*zps.Pool, error) {
 	return pool, nil
 }
 
+// Compares installed fs objects against a manifest, returning drifted actions and the reason for each
+func (m *Manager) verify(manifest *action.Manifest) (action.Actions, []string, error) {
+	var drifted action.Actions
+	var reasons []string
+
+	options := &provider.Options{TargetPath: m.config.CurrentImage.Path}
+	ctx := m.getContext(phase.VERIFY, options)
+
+	factory := m.factory()
+
+	diversions, err := NewDiversions(m.state)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contents := manifest.FsObjects()
+
+	for _, fsObject := range contents {
+		fsObject = diversions.Apply(manifest.Zpkg.Name, fsObject)
+
+		err := factory.Get(fsObject).Realize(ctx)
+		if err == nil {
+			continue
+		}
+
+		if !provider.IsDrift(err) {
+			return nil, nil, err
+		}
+
+		drifted = append(drifted, fsObject)
+		reasons = append(reasons, err.Error())
+	}
+
+	return drifted, reasons, nil
+}
+
+func (m *Manager) fixMode(target string, fileMode string, owner string, group string) error {
+	mode, err := strconv.ParseUint(fileMode, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	// Ownership only applies as a super user, as on install
+	uid, gid, err := provider.LookupIds(m.config.CurrentImage.Path, owner, group)
+	if err == nil {
+		os.Chown(target, uid, gid)
+	}
+
+	return os.Chmod(target, os.FileMode(mode))
+}
+
 func (m *Manager) repoConfig(uri string) (map[string]string, error) {
 	configPath := m.cache.GetConfig(uri)
 
@@ -1866,6 +2834,144 @@ func (m *Manager) repoConfig(uri string) (map[string]string, error) {
 	return cfg, nil
 }
 
+// lockedRequest pins requested packages, or every locked package if none are requested, to their locked ids.
+// Installing the whole lockfile also removes installed packages it does not list.
+func (m *Manager) lockedRequest(pool *zps.Pool, names []string) (*zps.Request, error) {
+	entries := m.locked.Packages
+
+	if len(names) > 0 {
+		entries = nil
+
+		for _, name := range names {
+			req, err := zps.NewRequirementFromSimpleString(name)
+			if err != nil {
+				return nil, err
+			}
+
+			entry := m.locked.Get(req.Name)
+			if entry == nil {
+				return nil, fmt.Errorf("%s is not locked in %s", req.Name, m.locked.FilePath)
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	request := zps.NewRequest()
+
+	for _, entry := range entries {
+		req, err := zps.NewRequirementFromSimpleString(strings.Join([]string{entry.Name, entry.Version}, "@"))
+		if err != nil {
+			return nil, err
+		}
+
+		var available zps.Solvable
+		for _, candidate := range pool.WhatProvides(req) {
+			if candidate.Name() == req.Name && candidate.Version().EXQ(req.Version) {
+				available = candidate
+				break
+			}
+		}
+
+		if available == nil {
+			return nil, fmt.Errorf("locked package %s@%s is no longer available", entry.Name, entry.Version)
+		}
+
+		if entry.Repo != "" && available.Priority() >= 0 && pool.Location(available.Location()).Uri != entry.Repo {
+			m.Emit("manager.warn", fmt.Sprintf("locked package %s@%s found in %s instead of %s", entry.Name, entry.Version, pool.Location(available.Location()).Uri, entry.Repo))
+		}
+
+		request.Install(req)
+	}
+
+	if len(names) > 0 {
+		return request, nil
+	}
+
+	for _, installed := range pool.Image() {
+		if m.locked.Get(installed.Name()) != nil {
+			continue
+		}
+
+		req, err := zps.NewRequirementFromSimpleString(installed.Id())
+		if err != nil {
+			return nil, err
+		}
+
+		m.Emit("manager.warn", fmt.Sprintf("%s is not locked in %s, removing", installed.Id(), m.locked.FilePath))
+		request.Remove(req)
+	}
+
+	return request, nil
+}
+
+// checkLocked fails if the solution strays from the lockfile rather than silently re-solving
+func (m *Manager) checkLocked(operations []*zps.Operation) error {
+	for _, op := range operations {
+		if op.Operation == phase.REMOVE {
+			continue
+		}
+
+		entry := m.locked.Get(op.Package.Name())
+
+		if entry == nil {
+			if op.Operation == phase.INSTALL {
+				return fmt.Errorf("%s is required but not locked in %s", op.Package.Id(), m.locked.FilePath)
+			}
+
+			continue
+		}
+
+		if op.Package.Id() != strings.Join([]string{entry.Name, entry.Version}, "@") {
+			return fmt.Errorf("%s does not match locked version %s", op.Package.Id(), entry.Version)
+		}
+
+		if entry.Publisher != "" && op.Package.(*zps.Pkg).Publisher() != entry.Publisher {
+			return fmt.Errorf("%s publisher %s does not match locked publisher %s", op.Package.Id(), op.Package.(*zps.Pkg).Publisher(), entry.Publisher)
+		}
+	}
+
+	return nil
+}
+
+// checkLockedChecksums compares fetched package files with the locked checksums
+func (m *Manager) checkLockedChecksums(operations []*zps.Operation) error {
+	for _, op := range operations {
+		entry := m.locked.Get(op.Package.Name())
+		if op.Operation != phase.INSTALL || entry == nil || entry.Digest == "" {
+			continue
+		}
+
+		_, checksum, err := FileChecksum(m.cache.GetFile(op.Package.FileName()))
+		if err != nil {
+			return err
+		}
+
+		if checksum != entry.Digest {
+			return fmt.Errorf("%s checksum does not match lockfile %s", op.Package.Id(), m.locked.FilePath)
+		}
+	}
+
+	return nil
+}
+
+// solverPolicy resolves the policy from the command, then the image config, defaulting to updated
+func (m *Manager) solverPolicy() zps.Policy {
+	if m.policy != "" {
+		return zps.NewPolicy(m.policy)
+	}
+
+	if m.config.CurrentImage.Policy != "" {
+		if policy := zps.NewPolicy(m.config.CurrentImage.Policy); policy != nil {
+			return policy
+		}
+
+		m.Emit("manager.warn", fmt.Sprintf("unsupported image policy %s, using updated", m.config.CurrentImage.Policy))
+	}
+
+	return zps.NewPolicy("updated")
+}
+
 func (m *Manager) splitReqsFiles(args []string) ([]string, []string, error) {
 	var reqs []string
 	var files []string
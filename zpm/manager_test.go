@@ -0,0 +1,152 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/chuckpreslar/emission"
+
+	"github.com/fezz-io/zps/config"
+	"github.com/fezz-io/zps/phase"
+	"github.com/fezz-io/zps/zps"
+)
+
+const (
+	testLocked  = "1.0.0:20210101T000000Z"
+	testRebuilt = "1.0.0:20210102T000000Z"
+)
+
+func testLockPkg(t *testing.T, name string, version string, publisher string) *zps.Pkg {
+	t.Helper()
+
+	pkg, err := zps.NewPkg(name, version, publisher, nil, "x86_64", "linux", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pkg
+}
+
+func testLockManager(packages ...*config.PkgConfig) *Manager {
+	return &Manager{Emitter: emission.NewEmitter(), locked: &config.LockFile{Packages: packages, FilePath: "zps.lock"}}
+}
+
+func TestManagerLockedRequest(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry *config.PkgConfig
+		err   bool
+	}{
+		{"locked", &config.PkgConfig{Name: "app", Version: testLocked}, false},
+		{"rebuilt", &config.PkgConfig{Name: "app", Version: testRebuilt}, true},
+		{"missing", &config.PkgConfig{Name: "other", Version: testLocked}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			repo := zps.NewRepo("test", 0, true, nil, zps.Solvables{testLockPkg(t, "app", testLocked, "test")})
+
+			pool, err := zps.NewPool(zps.NewRepo("image", -1, true, nil, nil), nil, repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request, err := testLockManager(test.entry).lockedRequest(pool, nil)
+			if test.err {
+				if err == nil {
+					t.Error("expected locked package to be unavailable")
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(request.Jobs()) != 1 {
+				t.Fatalf("got %d jobs, want 1", len(request.Jobs()))
+			}
+
+			job := request.Jobs()[0]
+			if job.Op() != "install" || job.Requirement().Name != "app" || job.Requirement().Version.String() != testLocked {
+				t.Errorf("got %s %s", job.Op(), job.Requirement())
+			}
+		})
+	}
+}
+
+func TestManagerCheckLocked(t *testing.T) {
+	locked := &config.PkgConfig{Name: "app", Version: testLocked, Publisher: "test"}
+
+	tests := []struct {
+		name      string
+		operation string
+		pkg       *zps.Pkg
+		err       bool
+	}{
+		{"locked", phase.INSTALL, testLockPkg(t, "app", testLocked, "test"), false},
+		{"version drift", phase.INSTALL, testLockPkg(t, "app", "1.1.0:20210101T000000Z", "test"), true},
+		{"timestamp drift", phase.INSTALL, testLockPkg(t, "app", testRebuilt, "test"), true},
+		{"publisher drift", phase.INSTALL, testLockPkg(t, "app", testLocked, "other"), true},
+		{"not locked", phase.INSTALL, testLockPkg(t, "lib", testLocked, "test"), true},
+		{"installed not locked", phase.NOOP, testLockPkg(t, "lib", testLocked, "test"), false},
+		{"removed", phase.REMOVE, testLockPkg(t, "app", testRebuilt, "test"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := testLockManager(locked).checkLocked([]*zps.Operation{zps.NewOperation(test.operation, test.pkg)})
+			if (err != nil) != test.err {
+				t.Errorf("got %v, want error %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestManagerCheckLockedChecksums(t *testing.T) {
+	tests := []struct {
+		name   string
+		digest string
+		err    bool
+	}{
+		{"matching", testChecksum(testContent), false},
+		{"drift", testChecksum([]byte("rebuilt content")), true},
+		{"no digest", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "zpm-lock")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			pkg := testLockPkg(t, "app", testLocked, "test")
+
+			err = ioutil.WriteFile(NewCache(dir).GetFile(pkg.FileName()), testContent, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			mgr := testLockManager(&config.PkgConfig{Name: "app", Version: testLocked, Digest: test.digest})
+			mgr.cache = NewCache(dir)
+
+			err = mgr.checkLockedChecksums([]*zps.Operation{zps.NewOperation(phase.INSTALL, pkg)})
+			if (err != nil) != test.err {
+				t.Errorf("got %v, want error %v", err, test.err)
+			}
+		})
+	}
+}
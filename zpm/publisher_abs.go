@@ -31,7 +31,6 @@ import (
 
 	"github.com/fezz-io/zps/cloud"
 	"github.com/fezz-io/zps/sec"
-	"github.com/fezz-io/zps/zpkg"
 	"github.com/fezz-io/zps/zps"
 )
 
@@ -270,14 +269,7 @@ func (a *ABSPublisher) Channel(pkg string, channel string) error {
 func (a *ABSPublisher) Publish(pkgs ...string) error {
 	zpkgs := make(map[string]*zps.Pkg)
 	for _, file := range pkgs {
-		reader := zpkg.NewReader(file, "")
-
-		err := reader.Read()
-		if err != nil {
-			return err
-		}
-
-		pkg, err := zps.NewPkgFromManifest(reader.Manifest)
+		pkg, err := readPublishPkg(file)
 		if err != nil {
 			return err
 		}
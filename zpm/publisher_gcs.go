@@ -28,7 +28,6 @@ import (
 	"google.golang.org/api/iterator"
 
 	"github.com/fezz-io/zps/sec"
-	"github.com/fezz-io/zps/zpkg"
 	"github.com/fezz-io/zps/zps"
 )
 
@@ -297,14 +296,7 @@ func (g *GCSPublisher) Channel(pkg string, channel string) error {
 func (g *GCSPublisher) Publish(pkgs ...string) error {
 	zpkgs := make(map[string]*zps.Pkg)
 	for _, file := range pkgs {
-		reader := zpkg.NewReader(file, "")
-
-		err := reader.Read()
-		if err != nil {
-			return err
-		}
-
-		pkg, err := zps.NewPkgFromManifest(reader.Manifest)
+		pkg, err := readPublishPkg(file)
 		if err != nil {
 			return err
 		}
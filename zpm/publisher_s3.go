@@ -33,7 +33,6 @@ import (
 	"github.com/chuckpreslar/emission"
 
 	"github.com/fezz-io/zps/sec"
-	"github.com/fezz-io/zps/zpkg"
 	"github.com/fezz-io/zps/zps"
 )
 
@@ -290,14 +289,7 @@ func (s *S3Publisher) Channel(pkg string, channel string) error {
 func (s *S3Publisher) Publish(pkgs ...string) error {
 	zpkgs := make(map[string]*zps.Pkg)
 	for _, file := range pkgs {
-		reader := zpkg.NewReader(file, "")
-
-		err := reader.Read()
-		if err != nil {
-			return err
-		}
-
-		pkg, err := zps.NewPkgFromManifest(reader.Manifest)
+		pkg, err := readPublishPkg(file)
 		if err != nil {
 			return err
 		}
@@ -11,6 +11,8 @@
 package zpm
 
 import (
+	"io"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -24,6 +26,9 @@ import (
 
 type State struct {
 	Path         string
+	Accounts     *StateAccounts
+	Alternatives *StateAlternatives
+	Diversions   *StateDiversions
 	Frozen       *StateFrozen
 	Packages     *StatePackages
 	Objects      *StateObjects
@@ -31,6 +36,18 @@ type State struct {
 	Transactions *StateTransactions
 }
 
+type StateAccounts struct {
+	getDb func() (*storm.DB, error)
+}
+
+type StateAlternatives struct {
+	getDb func() (*storm.DB, error)
+}
+
+type StateDiversions struct {
+	getDb func() (*storm.DB, error)
+}
+
 type StateFrozen struct {
 	getDb func() (*storm.DB, error)
 }
@@ -51,6 +68,33 @@ type StateTransactions struct {
 	getDb func() (*storm.DB, error)
 }
 
+// AccountEntry records a user or group created by zps rather than found in the image
+type AccountEntry struct {
+	Id string `storm:"id"`
+}
+
+// AlternativeEntry registers an installed package as a candidate for a link
+type AlternativeEntry struct {
+	Key      string `storm:"id"`
+	Link     string `storm:"index"`
+	Pkg      string `storm:"index"`
+	Target   string
+	Priority int
+}
+
+// AlternativeSelection records the package an admin chose to own a link
+type AlternativeSelection struct {
+	Link string `storm:"id"`
+	Pkg  string
+}
+
+// DiversionEntry records a diverted path, Pkg is the diverting package or empty for local diversions
+type DiversionEntry struct {
+	Path string `storm:"id"`
+	To   string
+	Pkg  string `storm:"index"`
+}
+
 type PkgEntry struct {
 	Name     string `storm:"id"`
 	Manifest []byte
@@ -86,10 +130,22 @@ type TransactionEntry struct {
 	PkgId     string
 	Operation string
 	Date      *time.Time `storm:"index"`
+
+	Reverts    string `storm:"index"`
+	RolledBack bool
 }
 
 func NewState(path string) *State {
 	state := &State{Path: path}
+	state.Accounts = &StateAccounts{}
+	state.Accounts.getDb = state.getDb
+
+	state.Alternatives = &StateAlternatives{}
+	state.Alternatives.getDb = state.getDb
+
+	state.Diversions = &StateDiversions{}
+	state.Diversions.getDb = state.getDb
+
 	state.Frozen = &StateFrozen{}
 	state.Frozen.getDb = state.getDb
 
@@ -108,6 +164,13 @@ func NewState(path string) *State {
 	return state
 }
 
+func NewAlternativeEntry(pkg string, alt *action.Alternative) *AlternativeEntry {
+	entry := &AlternativeEntry{Link: alt.Link, Pkg: pkg, Target: alt.Target, Priority: alt.Priority}
+	entry.Key = strings.Join([]string{alt.Link, pkg}, "\x00")
+
+	return entry
+}
+
 func NewFsEntry(path string, pkg string, typ string) *FsEntry {
 	fs := &FsEntry{Path: path, Pkg: pkg, Type: typ}
 	fs.Key = strings.Join([]string{path, pkg}, "\x00")
@@ -115,8 +178,8 @@ func NewFsEntry(path string, pkg string, typ string) *FsEntry {
 	return fs
 }
 
-func NewTransactionEntry(id string, pkgId string, operation string, date *time.Time) *TransactionEntry {
-	ts := &TransactionEntry{Id: id, PkgId: pkgId, Operation: operation, Date: date}
+func NewTransactionEntry(id string, pkgId string, operation string, reverts string, date *time.Time) *TransactionEntry {
+	ts := &TransactionEntry{Id: id, PkgId: pkgId, Operation: operation, Reverts: reverts, Date: date}
 	ts.Key = strings.Join([]string{id, pkgId}, "\x00")
 
 	return ts
@@ -131,6 +194,53 @@ func (s *State) getDb() (*storm.DB, error) {
 	return db, nil
 }
 
+// Snapshot writes a consistent copy of the image db to path, path only exists once the copy is complete
+func (s *State) Snapshot(path string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Bolt.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(path+".tmp", 0600)
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+// Restore replaces the image db with a copy previously written by Snapshot
+func (s *State) Restore(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := filepath.Join(s.Path, "image.db.restore")
+
+	dst, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	if err != nil {
+		dst.Close()
+		return err
+	}
+
+	err = dst.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(s.Path, "image.db"))
+}
+
 func (s *StatePackages) All() ([]*action.Manifest, error) {
 	db, err := s.getDb()
 	if err != nil {
@@ -249,6 +359,43 @@ func (s *StateObjects) Del(pkg string) error {
 	return err
 }
 
+// Divert moves the entries of every package other than the diverting one to the diverted path
+func (s *StateObjects) Divert(path string, to string, pkg string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var entries []*FsEntry
+
+	err = db.Find("Path", path, &entries)
+	if err == storm.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Pkg == pkg {
+			continue
+		}
+
+		err = db.DeleteStruct(entry)
+		if err != nil {
+			return err
+		}
+
+		err = db.Save(NewFsEntry(to, entry.Pkg, entry.Type))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *StateObjects) Put(path string, pkg string, typ string) error {
 	db, err := s.getDb()
 	if err != nil {
@@ -372,18 +519,37 @@ func (s *StateTransactions) Get(id string) ([]*TransactionEntry, error) {
 	return entries, nil
 }
 
-func (s *StateTransactions) Put(id string, pkgId string, operation string, date *time.Time) error {
+func (s *StateTransactions) Put(id string, pkgId string, operation string, reverts string, date *time.Time) error {
 	db, err := s.getDb()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	err = db.Save(NewTransactionEntry(id, pkgId, operation, date))
+	err = db.Save(NewTransactionEntry(id, pkgId, operation, reverts, date))
 
 	return err
 }
 
+func (s *StateTransactions) MarkRolledBack(entries []*TransactionEntry) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, entry := range entries {
+		entry.RolledBack = true
+
+		err = db.Save(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *StateFrozen) All() ([]*FrozenEntry, error) {
 	db, err := s.getDb()
 	if err != nil {
@@ -421,3 +587,206 @@ func (s *StateFrozen) Put(pkgId string) error {
 
 	return err
 }
+
+func (s *StateAlternatives) All() ([]*AlternativeEntry, error) {
+	db, err := s.getDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entries []*AlternativeEntry
+
+	err = db.All(&entries)
+
+	return entries, nil
+}
+
+func (s *StateAlternatives) Get(link string) ([]*AlternativeEntry, error) {
+	db, err := s.getDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entries []*AlternativeEntry
+
+	err = db.Find("Link", link, &entries)
+
+	return entries, nil
+}
+
+func (s *StateAlternatives) Del(pkg string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	query := db.Select(q.Eq("Pkg", pkg))
+	err = query.Delete(&AlternativeEntry{})
+
+	return err
+}
+
+func (s *StateAlternatives) Put(pkg string, alt *action.Alternative) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Save(NewAlternativeEntry(pkg, alt))
+
+	return err
+}
+
+// Selected returns the package an admin selected for a link, empty when the link is automatic
+func (s *StateAlternatives) Selected(link string) (string, error) {
+	db, err := s.getDb()
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var selection AlternativeSelection
+
+	err = db.One("Link", link, &selection)
+	if err == storm.ErrNotFound {
+		return "", nil
+	}
+
+	return selection.Pkg, err
+}
+
+func (s *StateAlternatives) Select(link string, pkg string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Save(&AlternativeSelection{link, pkg})
+
+	return err
+}
+
+// Auto drops a selection so the link follows priority again
+func (s *StateAlternatives) Auto(link string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.DeleteStruct(&AlternativeSelection{Link: link})
+	if err == storm.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (s *StateDiversions) All() ([]*DiversionEntry, error) {
+	db, err := s.getDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entries []*DiversionEntry
+
+	err = db.All(&entries)
+
+	return entries, nil
+}
+
+// Get returns the diversion of a path, nil if the path is not diverted
+func (s *StateDiversions) Get(path string) (*DiversionEntry, error) {
+	db, err := s.getDb()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entry DiversionEntry
+
+	err = db.One("Path", path, &entry)
+	if err == storm.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (s *StateDiversions) Del(path string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.DeleteStruct(&DiversionEntry{Path: path})
+
+	return err
+}
+
+func (s *StateDiversions) Put(path string, to string, pkg string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Save(&DiversionEntry{path, to, pkg})
+
+	return err
+}
+
+// Created reports whether an account was created by zps, id is the action id
+func (s *StateAccounts) Created(id string) (bool, error) {
+	db, err := s.getDb()
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	var entry AccountEntry
+
+	err = db.One("Id", id, &entry)
+	if err == storm.ErrNotFound {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (s *StateAccounts) Del(id string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.DeleteStruct(&AccountEntry{id})
+	if err == storm.ErrNotFound {
+		return nil
+	}
+
+	return err
+}
+
+func (s *StateAccounts) Put(id string) error {
+	db, err := s.getDb()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Save(&AccountEntry{id})
+
+	return err
+}
@@ -13,6 +13,9 @@ package zpm
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 
@@ -38,13 +41,36 @@ type Transaction struct {
 
 	solution *zps.Solution
 	readers  map[string]*zpkg.Reader
+	journal  *Journal
+	factory  *provider.Factory
 
-	id   ksuid.KSUID
-	date time.Time
+	// Paths installed or removed so far, matched against triggers once all operations are done
+	touched map[string]bool
+
+	// Alternative links whose candidates changed, relinked once all operations are done
+	alternatives map[string]bool
+
+	// Diversions registered in the image, updated as packages add or drop them
+	diversions Diversions
+
+	id      ksuid.KSUID
+	date    time.Time
+	reverts string
 }
 
 func NewTransaction(emitter *emission.Emitter, targetPath string, cache *Cache, state *State) *Transaction {
-	return &Transaction{emitter, targetPath, cache, state, nil, nil, ksuid.New(), time.Now()}
+	return &Transaction{emitter, targetPath, cache, state, nil, nil, nil, provider.DefaultFactory(emitter), make(map[string]bool), make(map[string]bool), nil, ksuid.New(), time.Now(), ""}
+}
+
+// Factory is the provider factory used to realize package actions, callers may select providers on it
+func (t *Transaction) Factory() *provider.Factory {
+	return t.factory
+}
+
+// Reverts marks this transaction as the rollback of a previously recorded transaction
+func (t *Transaction) Reverts(id string) *Transaction {
+	t.reverts = id
+	return t
 }
 
 func (t *Transaction) Realize(solution *zps.Solution) error {
@@ -56,12 +82,23 @@ func (t *Transaction) Realize(solution *zps.Solution) error {
 		return err
 	}
 
-	err = t.solutionConflicts()
+	t.diversions, err = NewDiversions(t.state)
 	if err != nil {
 		return err
 	}
 
-	err = t.imageConflicts()
+	// Conflicts are checked as if the diversions candidates declare were in place
+	planned, err := t.plannedDiversions()
+	if err != nil {
+		return err
+	}
+
+	err = t.solutionConflicts(planned)
+	if err != nil {
+		return err
+	}
+
+	err = t.imageConflicts(planned)
 	if err != nil {
 		return err
 	}
@@ -71,11 +108,32 @@ func (t *Transaction) Realize(solution *zps.Solution) error {
 		return err
 	}
 
+	err = t.begin()
+	if err != nil {
+		return err
+	}
+
+	err = t.realize(operations)
+	if err != nil {
+		rbErr := t.rollback()
+		if rbErr != nil {
+			return fmt.Errorf("%s, rollback failed: %s", err.Error(), rbErr.Error())
+		}
+
+		return err
+	}
+
+	return t.journal.Clean()
+}
+
+func (t *Transaction) realize(operations []*zps.Operation) error {
+	var err error
+
 	for _, operation := range operations {
 		switch operation.Operation {
 		case "remove":
 			t.Emit("transaction.remove", fmt.Sprint("removing ", operation.Package.Id()))
-			err = t.remove(operation.Package)
+			err = t.remove(operation.Package, nil)
 			if err != nil {
 				return err
 			}
@@ -93,32 +151,120 @@ func (t *Transaction) Realize(solution *zps.Solution) error {
 				}
 
 				t.Emit("transaction.remove", fmt.Sprint("removing ", lns.Id()))
-				err = t.remove(operation.Package)
+				err = t.remove(operation.Package, t.readers[operation.Package.Name()].Manifest)
 				if err != nil {
 					return err
 				}
 
-				err = t.state.Transactions.Put(t.id.String(), lns.Id(), "remove", &t.date)
+				err = t.state.Transactions.Put(t.id.String(), lns.Id(), "remove", t.reverts, &t.date)
 				if err != nil {
 					return err
 				}
 			}
 
 			t.Emit("transaction.install", fmt.Sprint("installing ", operation.Package.Id()))
-			err = t.install(operation.Package)
+			err = t.install(operation.Package, lookup)
 			if err != nil {
 				return err
 			}
 		}
 
 		if operation.Operation != "noop" {
-			err = t.state.Transactions.Put(t.id.String(), operation.Package.Id(), operation.Operation, &t.date)
+			err = t.state.Transactions.Put(t.id.String(), operation.Package.Id(), operation.Operation, t.reverts, &t.date)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
+	err = t.relinkAlternatives()
+	if err != nil {
+		return err
+	}
+
+	return t.triggers()
+}
+
+// Stage the image db, fs objects are staged as they are touched
+func (t *Transaction) begin() error {
+	t.journal = NewJournal(t.targetPath, filepath.Join(t.state.Path, "transactions", t.id.String()))
+
+	err := os.MkdirAll(t.journal.Path(), 0750)
+	if err != nil {
+		return err
+	}
+
+	return t.state.Snapshot(filepath.Join(t.journal.Path(), "image.db"))
+}
+
+// Restore the image and state db to their pre transaction contents
+func (t *Transaction) rollback() error {
+	t.Emit("transaction.rollback", fmt.Sprint("rolling back ", t.id.String()))
+
+	entries, err := t.state.Transactions.Get(t.id.String())
+	if err != nil {
+		return err
+	}
+
+	err = t.journal.Restore()
+	if err != nil {
+		return err
+	}
+
+	err = t.state.Restore(filepath.Join(t.journal.Path(), "image.db"))
+	if err != nil {
+		return err
+	}
+
+	err = t.state.Transactions.MarkRolledBack(entries)
+	if err != nil {
+		return err
+	}
+
+	return t.journal.Clean()
+}
+
+// RecoverTransactions rolls back transactions an interrupted run left behind, a transaction
+// only leaves its journal on disk when it neither completed nor rolled back
+func RecoverTransactions(emitter *emission.Emitter, targetPath string, cache *Cache, state *State) error {
+	journals, err := ioutil.ReadDir(filepath.Join(state.Path, "transactions"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, info := range journals {
+		id, err := ksuid.Parse(info.Name())
+		if err != nil || !info.IsDir() {
+			continue
+		}
+
+		t := NewTransaction(emitter, targetPath, cache, state)
+		t.id = id
+
+		t.journal, err = LoadJournal(targetPath, filepath.Join(state.Path, "transactions", info.Name()))
+		if err != nil {
+			return err
+		}
+
+		// Nothing is staged before the db snapshot is complete
+		if _, err := os.Stat(filepath.Join(t.journal.Path(), "image.db")); os.IsNotExist(err) {
+			err = t.journal.Clean()
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		err = t.rollback()
+		if err != nil {
+			return fmt.Errorf("recovering transaction %s: %s", id.String(), err.Error())
+		}
+	}
+
 	return nil
 }
 
@@ -130,6 +276,7 @@ func (t *Transaction) loadReaders() error {
 		if operation.Operation == "install" {
 			reader := zpkg.NewReader(t.cache.GetFile(operation.Package.FileName()), "")
 
+			// Read validates the manifest, nothing is staged for a package escaping the image root
 			err = reader.Read()
 			if err != nil {
 				return err
@@ -147,7 +294,46 @@ func (t *Transaction) loadReaders() error {
 	return err
 }
 
-func (t *Transaction) solutionConflicts() error {
+// plannedDiversions adds the diversions declared by candidates to the registered ones, a path
+// may only be diverted by a single package
+func (t *Transaction) plannedDiversions() (Diversions, error) {
+	planned := make(Diversions)
+	for divertPath, entry := range t.diversions {
+		planned[divertPath] = entry
+	}
+
+	for _, reader := range t.readers {
+		pkg, err := zps.NewPkgFromManifest(reader.Manifest)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, act := range reader.Manifest.Section("Divert") {
+			divert := act.(*action.Divert)
+
+			if entry, ok := planned[divert.Path]; ok && entry.Pkg != pkg.Name() {
+				owner := entry.Pkg
+				if owner == "" {
+					owner = "local diversion"
+				}
+
+				return nil, errors.New(fmt.Sprint(
+					"Divert ",
+					divert.Path,
+					" from candidate ",
+					pkg.Name(),
+					" conflicts with ",
+					owner))
+			}
+
+			planned[divert.Path] = &DiversionEntry{divert.Path, divert.To, pkg.Name()}
+		}
+	}
+
+	return planned, nil
+}
+
+func (t *Transaction) solutionConflicts(diversions Diversions) error {
 	var err error
 	var fsActions action.Actions
 	lookup := make(map[action.Action]*zps.Pkg)
@@ -158,7 +344,10 @@ func (t *Transaction) solutionConflicts() error {
 			return err
 		}
 
-		actions := reader.Manifest.Section("Dir", "File", "SymLink")
+		var actions action.Actions
+		for _, act := range reader.Manifest.Section(action.FsSections...) {
+			actions = append(actions, diversions.Apply(pkg.Name(), act))
+		}
 
 		// build lookup index, TODO revisit this
 		for _, act := range actions {
@@ -184,7 +373,7 @@ func (t *Transaction) solutionConflicts() error {
 	return err
 }
 
-func (t *Transaction) imageConflicts() error {
+func (t *Transaction) imageConflicts(diversions Diversions) error {
 	var err error
 
 	for _, reader := range t.readers {
@@ -194,15 +383,22 @@ func (t *Transaction) imageConflicts() error {
 			return err
 		}
 
-		for _, action := range reader.Manifest.Section("Dir", "File", "SymLink") {
-			fsEntries, err := t.state.Objects.Get(action.Key())
+		for _, fsObject := range reader.Manifest.Section(action.FsSections...) {
+			fsObject = diversions.Apply(pkg.Name(), fsObject)
+
+			fsEntries, err := t.state.Objects.Get(fsObject.Key())
 
 			if err != nil {
 				return err
 			}
 
+			// Files other packages have at a path the candidate diverts are moved aside on install
+			if divert, ok := diversions[fsObject.Key()]; ok && divert.Pkg == pkg.Name() {
+				fsEntries = nil
+			}
+
 			for _, entry := range fsEntries {
-				if entry.Pkg != pkg.Name() && entry.Type != "Dir" && action.Type() != "Dir" {
+				if entry.Pkg != pkg.Name() && entry.Type != "Dir" && fsObject.Type() != "Dir" {
 					return errors.New(fmt.Sprint(
 						entry.Type,
 						" ",
@@ -213,38 +409,108 @@ func (t *Transaction) imageConflicts() error {
 						pkg.Name()))
 				}
 			}
+
+			alternatives, err := t.state.Alternatives.Get(fsObject.Key())
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range alternatives {
+				if entry.Pkg != pkg.Name() {
+					return errors.New(fmt.Sprint(
+						"Alternative ",
+						entry.Link,
+						" from installed pkg ",
+						entry.Pkg,
+						" conflicts with candidate ",
+						pkg.Name()))
+				}
+			}
+		}
+
+		// Links shared through alternatives may not be owned as a file by another package
+		for _, alt := range reader.Manifest.Section("Alternative") {
+			fsEntries, err := t.state.Objects.Get(alt.Key())
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range fsEntries {
+				if entry.Pkg != pkg.Name() {
+					return errors.New(fmt.Sprint(
+						entry.Type,
+						" ",
+						entry.Path,
+						" from installed pkg ",
+						entry.Pkg,
+						" conflicts with candidate alternative of ",
+						pkg.Name()))
+				}
+			}
 		}
 	}
 
 	return err
 }
 
-func (t *Transaction) install(pkg zps.Solvable) error {
+// previous is the manifest of the version being upgraded from, if any
+func (t *Transaction) install(pkg zps.Solvable, previous *action.Manifest) error {
 	reader := t.readers[pkg.Name()]
 
 	// Setup context
 	ctx := context.WithValue(context.Background(), "options", &provider.Options{TargetPath: t.targetPath})
 	ctx = context.WithValue(ctx, "phase", phase.INSTALL)
 	ctx = context.WithValue(ctx, "payload", reader.Payload)
+	ctx = context.WithValue(ctx, "previous", previous)
+	ctx = context.WithValue(ctx, "manifest", reader.Manifest)
 
-	// Provider Factory
-	factory := provider.DefaultFactory(t.Emitter)
+	factory := t.factory
 
 	pkg, err := zps.NewPkgFromManifest(reader.Manifest)
 	if err != nil {
 		return err
 	}
 
-	var contents action.Actions
-	contents = reader.Manifest.Section("Dir", "File", "SymLink")
+	// Accounts come first so hooks and fs objects can be owned by them
+	accounts := reader.Manifest.Section("Group")
+	sort.Sort(accounts)
+
+	users := reader.Manifest.Section("User")
+	sort.Sort(users)
+
+	err = t.accounts(ctx, factory, append(accounts, users...))
+	if err != nil {
+		return err
+	}
+
+	err = t.hooks(ctx, factory, reader.Manifest, action.HookPreInstall)
+	if err != nil {
+		return err
+	}
+
+	// Files other packages installed at diverted paths are moved aside before ours are written
+	err = t.divert(ctx, factory, pkg.Name(), reader.Manifest)
+	if err != nil {
+		return err
+	}
 
-	sort.Sort(contents)
+	contents := reader.Manifest.FsObjects()
+	for index, fsObject := range contents {
+		contents[index] = t.diversions.Apply(pkg.Name(), fsObject)
+	}
 
 	for _, fsObject := range contents {
+		err = t.stage(fsObject, ".zpsnew")
+		if err != nil {
+			return err
+		}
+
 		err = factory.Get(fsObject).Realize(ctx)
 		if err != nil {
 			return err
 		}
+
+		t.touched[fsObject.Key()] = true
 	}
 
 	// Add this to the package db
@@ -271,10 +537,31 @@ func (t *Transaction) install(pkg zps.Solvable) error {
 		}
 	}
 
-	return err
+	// Register alternatives, links are updated once all operations are done
+	for _, alt := range reader.Manifest.Section("Alternative") {
+		err = t.state.Alternatives.Put(pkg.Name(), alt.(*action.Alternative))
+		if err != nil {
+			return err
+		}
+
+		t.alternatives[alt.Key()] = true
+	}
+
+	if previous != nil {
+		err = t.hooks(ctx, factory, reader.Manifest, action.HookPostUpgrade)
+	} else {
+		err = t.hooks(ctx, factory, reader.Manifest, action.HookPostInstall)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Services start or restart once the new files and hooks are in place
+	return t.services(ctx, factory, reader.Manifest)
 }
 
-func (t *Transaction) remove(pkg zps.Solvable) error {
+// next is the manifest of the version being upgraded to, if any
+func (t *Transaction) remove(pkg zps.Solvable, next *action.Manifest) error {
 	lookup, err := t.state.Packages.Get(pkg.Name())
 	if err != nil {
 		return err
@@ -284,37 +571,90 @@ func (t *Transaction) remove(pkg zps.Solvable) error {
 		// Setup context
 		ctx := context.WithValue(context.Background(), "options", &provider.Options{TargetPath: t.targetPath})
 		ctx = context.WithValue(ctx, "phase", phase.REMOVE)
+		ctx = context.WithValue(ctx, "next", next)
+		ctx = context.WithValue(ctx, "manifest", lookup)
 
-		// Provider Factory
-		factory := provider.DefaultFactory(t.Emitter)
+		factory := t.factory
 
 		pkg, err := zps.NewPkgFromManifest(lookup)
 		if err != nil {
 			return err
 		}
 
+		// Remove hooks are skipped when the package is being replaced by an upgrade
+		if next == nil {
+			err = t.hooks(ctx, factory, lookup, action.HookPreRemove)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Stop services before their files are removed
+		err = t.services(ctx, factory, lookup)
+		if err != nil {
+			return err
+		}
+
 		var contents action.Actions
-		contents = lookup.Section("Dir", "File", "SymLink")
+		contents = lookup.Section(action.FsSections...)
 
 		// Reverse the actionlist
 		sort.Sort(sort.Reverse(contents))
 
 		for _, fsObject := range contents {
+			fsObject = t.diversions.Apply(pkg.Name(), fsObject)
+
+			err = t.stage(fsObject, ".zpssave")
+			if err != nil {
+				return err
+			}
+
 			err = factory.Get(fsObject).Realize(ctx)
 			if err != nil {
 				return err
 			}
+
+			t.touched[fsObject.Key()] = true
+		}
+
+		// Diverted files return to their path once ours are gone
+		err = t.undivert(ctx, factory, pkg.Name(), lookup, next)
+		if err != nil {
+			return err
+		}
+
+		// Drop links pointing into this package, another candidate may take them over
+		for _, alt := range lookup.Section("Alternative") {
+			err = t.journal.Stage(alt.Key())
+			if err != nil {
+				return err
+			}
+
+			err = factory.Get(alt).Realize(ctx)
+			if err != nil {
+				return err
+			}
+
+			t.alternatives[alt.Key()] = true
 		}
 
-		// Remove service entities
-		svcs := lookup.Section("Service")
-		for _, svc := range svcs {
-			err = factory.Get(svc).Realize(ctx)
+		if next == nil {
+			err = t.hooks(ctx, factory, lookup, action.HookPostRemove)
 			if err != nil {
 				return err
 			}
 		}
 
+		unused, err := t.unusedAccounts(lookup, next)
+		if err != nil {
+			return err
+		}
+
+		err = t.accounts(ctx, factory, unused)
+		if err != nil {
+			return err
+		}
+
 		// Remove from the package db
 		err = t.state.Packages.Del(pkg.Name())
 		if err != nil {
@@ -342,7 +682,335 @@ func (t *Transaction) remove(pkg zps.Solvable) error {
 				return err
 			}
 		}
+
+		// Remove alternatives from the alternatives db
+		err = t.state.Alternatives.Del(pkg.Name())
+		if err != nil {
+			if !strings.Contains(err.Error(), "not found") {
+				return err
+			}
+		}
 	}
 
 	return err
 }
+
+// stage journals an fs object along with the side file a config file may be written to
+func (t *Transaction) stage(fsObject action.Action, suffix string) error {
+	err := t.journal.Stage(fsObject.Key())
+	if err != nil {
+		return err
+	}
+
+	if file, ok := fsObject.(*action.File); ok && file.Config {
+		return t.journal.Stage(file.Key() + suffix)
+	}
+
+	return nil
+}
+
+// hooks runs the hooks a package defines for a lifecycle phase in name order,
+// a failing hook aborts the transaction
+func (t *Transaction) hooks(ctx context.Context, factory *provider.Factory, manifest *action.Manifest, hookPhase string) error {
+	hooks := manifest.Section("Hook")
+	sort.Sort(hooks)
+
+	for _, hook := range hooks {
+		if hook.(*action.Hook).Phase != hookPhase {
+			continue
+		}
+
+		err := factory.Get(hook).Realize(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// divert registers the diversions a package declares, files other packages installed at a
+// diverted path are moved to the diversion
+func (t *Transaction) divert(ctx context.Context, factory *provider.Factory, pkgName string, manifest *action.Manifest) error {
+	for _, act := range manifest.Section("Divert") {
+		divert := act.(*action.Divert)
+
+		// Kept across an upgrade
+		if entry, ok := t.diversions[divert.Path]; ok && entry.Pkg == pkgName && entry.To == divert.To {
+			continue
+		}
+
+		for _, objectPath := range []string{divert.Path, divert.To} {
+			err := t.journal.Stage(objectPath)
+			if err != nil {
+				return err
+			}
+
+			t.touched[objectPath] = true
+		}
+
+		err := factory.Get(divert).Realize(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = t.state.Diversions.Put(divert.Path, divert.To, pkgName)
+		if err != nil {
+			return err
+		}
+
+		err = t.state.Objects.Divert(divert.Path, divert.To, pkgName)
+		if err != nil {
+			return err
+		}
+
+		t.diversions[divert.Path] = &DiversionEntry{divert.Path, divert.To, pkgName}
+	}
+
+	return nil
+}
+
+// undivert drops the diversions of a package that the next version does not keep, diverted
+// files are moved back to their path
+func (t *Transaction) undivert(ctx context.Context, factory *provider.Factory, pkgName string, manifest *action.Manifest, next *action.Manifest) error {
+	for _, act := range manifest.Section("Divert") {
+		divert := act.(*action.Divert)
+
+		kept := false
+		if next != nil {
+			for _, nextAct := range next.Section("Divert") {
+				if nextAct.Key() == divert.Path && nextAct.(*action.Divert).To == divert.To {
+					kept = true
+				}
+			}
+		}
+
+		if kept {
+			continue
+		}
+
+		for _, objectPath := range []string{divert.Path, divert.To} {
+			err := t.journal.Stage(objectPath)
+			if err != nil {
+				return err
+			}
+
+			t.touched[objectPath] = true
+		}
+
+		err := factory.Get(divert).Realize(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = t.state.Diversions.Del(divert.Path)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return err
+		}
+
+		err = t.state.Objects.Divert(divert.To, divert.Path, pkgName)
+		if err != nil {
+			return err
+		}
+
+		delete(t.diversions, divert.Path)
+	}
+
+	return nil
+}
+
+// relinkAlternatives points every link whose candidates changed at its current owner
+func (t *Transaction) relinkAlternatives() error {
+	var links []string
+	for link := range t.alternatives {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+
+	for _, link := range links {
+		err := t.journal.Stage(link)
+		if err != nil {
+			return err
+		}
+
+		err = relinkAlternative(t.Emitter, t.state, t.factory, t.targetPath, link)
+		if err != nil {
+			return err
+		}
+
+		t.touched[link] = true
+	}
+
+	return nil
+}
+
+// triggers runs every trigger declared by the installed packages whose watched paths were
+// touched by the transaction, each trigger name runs once in package and trigger name order
+func (t *Transaction) triggers() error {
+	if len(t.touched) == 0 {
+		return nil
+	}
+
+	installed, err := t.state.Packages.All()
+	if err != nil {
+		return err
+	}
+
+	sort.SliceStable(installed, func(i, j int) bool { return installed[i].Zpkg.Name < installed[j].Zpkg.Name })
+
+	var touched []string
+	for objectPath := range t.touched {
+		touched = append(touched, objectPath)
+	}
+	sort.Strings(touched)
+
+	ran := make(map[string]bool)
+
+	for _, manifest := range installed {
+		triggers := manifest.Section("Trigger")
+		sort.Sort(triggers)
+
+		for _, act := range triggers {
+			trigger := act.(*action.Trigger)
+			if ran[trigger.Name] {
+				continue
+			}
+
+			var matched []string
+			for _, objectPath := range touched {
+				if trigger.Watches(objectPath) {
+					matched = append(matched, objectPath)
+				}
+			}
+
+			if len(matched) == 0 {
+				continue
+			}
+
+			ran[trigger.Name] = true
+
+			ctx := context.WithValue(context.Background(), "options", &provider.Options{TargetPath: t.targetPath})
+			ctx = context.WithValue(ctx, "phase", phase.INSTALL)
+			ctx = context.WithValue(ctx, "manifest", manifest)
+			ctx = context.WithValue(ctx, "triggered", matched)
+
+			err = t.factory.Get(trigger).Realize(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// services realizes the service actions of a package in name order
+func (t *Transaction) services(ctx context.Context, factory *provider.Factory, manifest *action.Manifest) error {
+	services := manifest.Section("Service")
+	sort.Sort(services)
+
+	for _, service := range services {
+		err := factory.Get(service).Realize(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// accounts realizes user and group actions, the account databases are journaled first
+func (t *Transaction) accounts(ctx context.Context, factory *provider.Factory, accounts action.Actions) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	err := t.journal.Stage(filepath.Dir(provider.PasswdPath))
+	if err != nil {
+		return err
+	}
+
+	for _, accountPath := range provider.AccountPaths {
+		err = t.journal.Stage(accountPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	install := ctx.Value("phase") == phase.INSTALL
+
+	for _, account := range accounts {
+		// Accounts already present may belong to the base system or an administrator,
+		// only the ones created here are recorded for removal
+		exists := false
+		if install {
+			exists, err = provider.AccountExists(t.targetPath, account)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = factory.Get(account).Realize(ctx)
+		if err != nil {
+			return err
+		}
+
+		if !install {
+			err = t.state.Accounts.Del(account.Id())
+		} else if !exists {
+			err = t.state.Accounts.Put(account.Id())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unusedAccounts lists the users and groups of a package being removed that zps created and
+// that neither its replacement nor any other installed package declares, users precede their groups
+func (t *Transaction) unusedAccounts(manifest *action.Manifest, next *action.Manifest) (action.Actions, error) {
+	installed, err := t.state.Packages.All()
+	if err != nil {
+		return nil, err
+	}
+
+	if next != nil {
+		installed = append(installed, next)
+	}
+
+	declared := make(map[string]bool)
+	for _, other := range installed {
+		if other != next && other.Zpkg.Name == manifest.Zpkg.Name {
+			continue
+		}
+
+		for _, account := range other.Section("Group", "User") {
+			declared[account.Id()] = true
+		}
+	}
+
+	var unused action.Actions
+	for _, section := range []string{"User", "Group"} {
+		accounts := manifest.Section(section)
+		sort.Sort(accounts)
+
+		for _, account := range accounts {
+			if declared[account.Id()] {
+				continue
+			}
+
+			created, err := t.state.Accounts.Created(account.Id())
+			if err != nil {
+				return nil, err
+			}
+
+			if created {
+				unused = append(unused, account)
+			}
+		}
+	}
+
+	return unused, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zpm
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/chuckpreslar/emission"
+	"github.com/fezz-io/zps/action"
+)
+
+func TestUnusedAccounts(t *testing.T) {
+	manifest := func(name string, accounts ...action.Action) *action.Manifest {
+		pkg := action.NewManifest()
+		pkg.Zpkg = &action.Zpkg{Name: name, Version: "1.0.0"}
+
+		for _, account := range accounts {
+			pkg.Add(account)
+		}
+
+		return pkg
+	}
+
+	app := manifest("app",
+		&action.Group{Name: "app"},
+		&action.Group{Name: "shared"},
+		&action.Group{Name: "wheel"},
+		&action.User{Name: "app", Group: "app"},
+	)
+
+	tests := []struct {
+		name     string
+		next     *action.Manifest
+		expected []string
+	}{
+		{
+			name:     "remove",
+			expected: []string{"User.app", "Group.app"},
+		},
+		{
+			name:     "upgrade keeps accounts",
+			next:     manifest("app", &action.Group{Name: "app"}, &action.User{Name: "app", Group: "app"}),
+			expected: nil,
+		},
+		{
+			name:     "upgrade drops the user",
+			next:     manifest("app", &action.Group{Name: "app"}),
+			expected: []string{"User.app"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "zpm-accounts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+
+			state := NewState(root)
+
+			// Shared is still declared by another package, wheel predates zps
+			for name, pkg := range map[string]*action.Manifest{"app": app, "other": manifest("other", &action.Group{Name: "shared"})} {
+				err = state.Packages.Put(name, pkg)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, id := range []string{"Group.app", "Group.shared", "User.app"} {
+				err = state.Accounts.Put(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			unused, err := NewTransaction(emission.NewEmitter(), root, nil, state).unusedAccounts(app, test.next)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var ids []string
+			for _, account := range unused {
+				ids = append(ids, account.Id())
+			}
+
+			if !reflect.DeepEqual(ids, test.expected) {
+				t.Errorf("got %v, want %v", ids, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zps
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fezz-io/sat"
+)
+
+// Bounds the satisfiability checks made while minimizing, the sat solver enumerates every
+// solution of each check so large pools make them expensive
+const explainBudget = 64
+
+// Reason records, in package terms, why a clause was handed to the sat solver
+type Reason struct {
+	Kind        string
+	Solvable    Solvable
+	Requirement *Requirement
+	Candidates  Solvables
+}
+
+type clause struct {
+	literals []sat.LiteralEncoder
+	reason   *Reason
+}
+
+func (r *Reason) String() string {
+	switch r.Kind {
+	case "install":
+		return fmt.Sprint("install of ", label(r.Solvable), " requested")
+	case "remove":
+		return fmt.Sprint("removal of ", label(r.Solvable), " requested")
+	case "depends":
+		if len(r.Candidates) == 0 {
+			return fmt.Sprint(label(r.Solvable), " depends on ", r.Requirement.String(), ", no candidates available")
+		}
+
+		return fmt.Sprint(label(r.Solvable), " depends on ", r.Requirement.String())
+	case "single":
+		return fmt.Sprint("only one of ", label(r.Candidates[0]), " or ", label(r.Candidates[1]), " may be installed")
+	case "conflicts":
+		return fmt.Sprint(label(r.Solvable), " conflicts with ", label(r.Candidates[0]))
+	case "dependent":
+		return fmt.Sprint(label(r.Solvable), " depends on ", r.Requirement.Name, " which is being removed")
+	}
+
+	return ""
+}
+
+// UnsatisfiableError carries the minimal set of reasons that make a request impossible,
+// approximate sets still conflict but may carry reasons that are not required
+type UnsatisfiableError struct {
+	Reasons     []*Reason
+	Approximate bool
+}
+
+func (e *UnsatisfiableError) Error() string {
+	return fmt.Sprint("zps.solver: No solution for requested jobs: ", strings.Join(e.Explain(), "; "))
+}
+
+// Explain renders each reason followed by any frozen packages involved
+func (e *UnsatisfiableError) Explain() []string {
+	var lines []string
+	frozen := make(map[string]bool)
+
+	for _, reason := range e.Reasons {
+		lines = append(lines, reason.String())
+	}
+
+	for _, reason := range e.Reasons {
+		for _, solvable := range append(Solvables{reason.Solvable}, reason.Candidates...) {
+			if solvable == nil || solvable.Priority() != -2 || frozen[solvable.Id()] {
+				continue
+			}
+
+			frozen[solvable.Id()] = true
+			lines = append(lines, fmt.Sprint(solvable.Id(), " is frozen"))
+		}
+	}
+
+	if e.Approximate {
+		lines = append(lines, "explanation is approximate, some of the above may not be involved")
+	}
+
+	return lines
+}
+
+func label(solvable Solvable) string {
+	if solvable.Priority() <= -1 {
+		return fmt.Sprint(solvable.Id(), " (installed)")
+	}
+
+	return solvable.Id()
+}
+
+// explain reports the reasons behind an unsatisfiable core of the clause set
+func (s *Solver) explain() error {
+	core, approximate := minimize(s.clauses, satisfiable)
+
+	err := &UnsatisfiableError{Approximate: approximate}
+	for _, c := range core {
+		err.Reasons = append(err.Reasons, c.reason)
+	}
+
+	return err
+}
+
+// minimize reduces clauses to an unsatisfiable core by deletion, a clause is kept only if the
+// remaining clauses become satisfiable without it. Clauses are dropped in halving chunks so the
+// parts of the pool unrelated to the conflict go in a few checks, the final pass over single
+// clauses makes the core minimal unless the budget runs out first.
+func minimize(clauses []*clause, check func([]*clause) bool) ([]*clause, bool) {
+	core := clauses
+	checks := 0
+
+	for size := len(core) / 2; size >= 1; size /= 2 {
+		for index := 0; index < len(core); {
+			if checks == explainBudget {
+				return core, true
+			}
+
+			end := index + size
+			if end > len(core) {
+				end = len(core)
+			}
+
+			candidate := make([]*clause, 0, len(core)-(end-index))
+			candidate = append(candidate, core[:index]...)
+			candidate = append(candidate, core[end:]...)
+
+			checks++
+			if check(candidate) {
+				index = end
+			} else {
+				core = candidate
+			}
+		}
+	}
+
+	return core, false
+}
+
+func satisfiable(clauses []*clause) bool {
+	solver := sat.NewSolver()
+
+	for _, c := range clauses {
+		solver.AddClause(c.literals...)
+	}
+
+	ok, _ := solver.Satisfiable()
+	return ok
+}
@@ -0,0 +1,139 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zps
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExplainMinimize(t *testing.T) {
+	spread := make([]int, 40)
+	for index := range spread {
+		spread[index] = index * 25
+	}
+
+	tests := []struct {
+		name        string
+		clauses     int
+		conflict    []int
+		approximate bool
+	}{
+		{"single", 1, []int{0}, false},
+		{"pair", 10, []int{2, 7}, false},
+		{"all", 3, []int{0, 1, 2}, false},
+		{"large pool", 200, []int{5, 150}, false},
+		{"over budget", 1000, spread, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var clauses []*clause
+			for index := 0; index < test.clauses; index++ {
+				clauses = append(clauses, &clause{reason: &Reason{Kind: fmt.Sprint(index)}})
+			}
+
+			// The clauses conflict only while every clause of the conflict is present
+			checks := 0
+			check := func(candidate []*clause) bool {
+				checks++
+
+				present := make(map[string]bool)
+				for _, c := range candidate {
+					present[c.reason.Kind] = true
+				}
+
+				for _, index := range test.conflict {
+					if !present[fmt.Sprint(index)] {
+						return true
+					}
+				}
+
+				return false
+			}
+
+			core, approximate := minimize(clauses, check)
+
+			if approximate != test.approximate {
+				t.Errorf("approximate: got %v, want %v", approximate, test.approximate)
+			}
+
+			if checks > explainBudget {
+				t.Errorf("checks: got %d, budget is %d", checks, explainBudget)
+			}
+
+			if check(core) {
+				t.Fatal("core is satisfiable")
+			}
+
+			if approximate {
+				return
+			}
+
+			var kept []string
+			for _, c := range core {
+				kept = append(kept, c.reason.Kind)
+			}
+
+			var expected []string
+			for _, index := range test.conflict {
+				expected = append(expected, fmt.Sprint(index))
+			}
+
+			if !reflect.DeepEqual(kept, expected) {
+				t.Errorf("core: got %v, want %v", kept, expected)
+			}
+		})
+	}
+}
+
+func TestUnsatisfiableErrorExplain(t *testing.T) {
+	installed := testPkg(t, "lib", "1.0.0", -2)
+	candidate := testPkg(t, "lib", "2.0.0", 0)
+	app := testPkg(t, "app", "1.0.0", 0)
+
+	requirement := NewRequirement("lib", candidate.Version()).GTE()
+
+	unsat := &UnsatisfiableError{
+		Reasons: []*Reason{
+			{Kind: "install", Solvable: app},
+			{Kind: "depends", Solvable: app, Requirement: requirement, Candidates: Solvables{candidate}},
+			{Kind: "single", Candidates: Solvables{installed, candidate}},
+		},
+		Approximate: true,
+	}
+
+	expected := []string{
+		"install of " + app.Id() + " requested",
+		app.Id() + " depends on lib >= 2.0.0",
+		"only one of " + installed.Id() + " (installed) or " + candidate.Id() + " may be installed",
+		installed.Id() + " is frozen",
+		"explanation is approximate, some of the above may not be involved",
+	}
+
+	if lines := unsat.Explain(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("got %q\nwant %q", lines, expected)
+	}
+}
+
+func testPkg(t *testing.T, name string, version string, priority int) *Pkg {
+	t.Helper()
+
+	pkg, err := NewPkg(name, version, "test", nil, "x86_64", "linux", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg.SetPriority(priority)
+
+	return pkg
+}
@@ -32,6 +32,10 @@ type Pkg struct {
 
 	channels []string
 
+	// Size and sha256 of the zpkg file as recorded in repository metadata
+	size     int64
+	checksum string
+
 	location int
 	priority int
 }
@@ -51,6 +55,9 @@ type PkgEntry struct {
 	Description string
 
 	Channels []string
+
+	Size     int64
+	Checksum string
 }
 
 func NewPkg(name string, version string, publisher string, reqs []*Requirement, arch string, os string, summary string, description string) (*Pkg, error) {
@@ -59,7 +66,7 @@ func NewPkg(name string, version string, publisher string, reqs []*Requirement,
 	if err != nil {
 		return nil, err
 	}
-	return &Pkg{reqs, name, ver, publisher, arch, os, summary, description, nil, 0, 0}, nil
+	return &Pkg{reqs, name, ver, publisher, arch, os, summary, description, nil, 0, "", 0, 0}, nil
 }
 
 func NewPkgFromManifest(manifest *action.Manifest) (*Pkg, error) {
@@ -82,25 +89,34 @@ func NewPkgFromManifest(manifest *action.Manifest) (*Pkg, error) {
 	pkg.description = zpkg.Description
 
 	for _, raction := range manifest.Section("Requirement") {
-		req := NewRequirement(raction.(*action.Requirement).Name, nil)
+		ract := raction.(*action.Requirement)
+		var req *Requirement
+
+		switch ract.Method {
+		case "provides":
+			// A provide is a capability, the version if any is what is provided
+			req = NewRequirement(ract.Name, nil).Provides().ANY()
+
+			if ract.Version != "" {
+				if IsRangeExpression(ract.Version) {
+					return nil, fmt.Errorf("zps.Pkg: provides %s requires a version not a range", ract.Name)
+				}
 
-		if raction.(*action.Requirement).Version != "" {
-			version := &Version{}
-			err := version.Parse(raction.(*action.Requirement).Version)
+				version, err := parseBound(ract.Version)
+				if err != nil {
+					return nil, err
+				}
+
+				req.Version = version
+				req.EQ()
+			}
+		default:
+			req, err = NewRequirementFromExpression(ract.Name, ract.Operation, ract.Version)
 			if err != nil {
 				return nil, err
 			}
 
-			req.Version = version
-		}
-
-		switch raction.(*action.Requirement).Method {
-		case "depends":
-			req = req.Depends().Op(raction.(*action.Requirement).Operation)
-		case "conflicts":
-			req = req.Conflicts().Op(raction.(*action.Requirement).Operation)
-		case "provides":
-			req = req.Provides().ANY()
+			req.Method = ract.Method
 		}
 
 		pkg.reqs = append(pkg.reqs, req)
@@ -145,6 +161,22 @@ func (p *Pkg) Os() string {
 	return p.os
 }
 
+func (p *Pkg) Size() int64 {
+	return p.size
+}
+
+func (p *Pkg) SetSize(size int64) {
+	p.size = size
+}
+
+func (p *Pkg) Checksum() string {
+	return p.checksum
+}
+
+func (p *Pkg) SetChecksum(checksum string) {
+	p.checksum = checksum
+}
+
 func (p *Pkg) Location() int {
 	return p.location
 }
@@ -161,18 +193,51 @@ func (p *Pkg) SetPriority(priority int) {
 	p.priority = priority
 }
 
+func (p *Pkg) Provides() []*Requirement {
+	var provides []*Requirement
+
+	for _, req := range p.reqs {
+		if req.Method == "provides" {
+			provides = append(provides, req)
+		}
+	}
+
+	return provides
+}
+
+// ProvidesString renders provided capabilities as a comma separated list of name[@version]
+func (p *Pkg) ProvidesString() string {
+	var provides []string
+
+	for _, provide := range p.Provides() {
+		if provide.Version != nil {
+			provides = append(provides, strings.Join([]string{provide.Name, provide.Version.Short()}, "@"))
+		} else {
+			provides = append(provides, provide.Name)
+		}
+	}
+
+	return strings.Join(provides, ", ")
+}
+
 func (p *Pkg) Satisfies(req *Requirement) bool {
-	switch req.Operation {
-	case 3:
-		return true
-	case 2:
-		return p.version.EXQ(req.Version)
-	case 1:
-		return p.version.GTE(req.Version)
-	case 0:
-		return p.version.EQ(req.Version)
-	case -1:
-		return p.version.LTE(req.Version)
+	if req.Name == p.name {
+		return req.Matches(p.version)
+	}
+
+	// Virtual requirement, unversioned provides only satisfy unversioned requirements
+	for _, provide := range p.Provides() {
+		if provide.Name != req.Name {
+			continue
+		}
+
+		if req.Operation == 3 {
+			return true
+		}
+
+		if provide.Version != nil && req.Matches(provide.Version) {
+			return true
+		}
 	}
 
 	return false
@@ -220,6 +285,8 @@ func (p *Pkg) ToEntry() *PkgEntry {
 		Summary:      p.Summary(),
 		Description:  p.Description(),
 		Channels:     p.Channels(),
+		Size:         p.Size(),
+		Checksum:     p.Checksum(),
 	}
 }
 
@@ -234,5 +301,7 @@ func (p *PkgEntry) ToPkg() *Pkg {
 		summary:     p.Summary,
 		description: p.Description,
 		channels:    p.Channels,
+		size:        p.Size,
+		checksum:    p.Checksum,
 	}
 }
@@ -22,6 +22,10 @@ type Policy interface {
 
 type UpdatedPolicy struct{}
 type InstalledPolicy struct{}
+type MinimalPolicy struct{}
+type NewestPolicy struct{}
+type LowestPolicy struct{}
+type PinnedPolicy struct{}
 
 func NewPolicy(method string) Policy {
 	switch method {
@@ -29,32 +33,39 @@ func NewPolicy(method string) Policy {
 		return &UpdatedPolicy{}
 	case "installed":
 		return &InstalledPolicy{}
+	case "minimal-change":
+		return &MinimalPolicy{}
+	case "newest":
+		return &NewestPolicy{}
+	case "lowest-satisfying":
+		return &LowestPolicy{}
+	case "repo-pinned":
+		return &PinnedPolicy{}
 	}
 
 	return nil
 }
 
 func (u *UpdatedPolicy) PruneProvides(solvables Solvables) Solvables {
-	if len(solvables) == 0 {
-		return solvables
-	}
-
-	sort.Sort(solvables)
-
-	if solvables[0].Priority() == -2 {
-		return Solvables{solvables[0]}
-	}
-
-	return solvables
+	return pruneFrozen(solvables)
 }
 
 func (u *UpdatedPolicy) SelectRequest(solvables Solvables) Solvable {
-	sort.Sort(solvables)
+	sort.Sort(candidates(solvables))
 
 	for _, solvable := range solvables {
-		if len(solvables) > 1 && solvable.Priority() == -1 {
-			if solvables[1].Version().GT(solvables[0].Version()) {
-				return solvables[1]
+		// Prefer an update of the installed provider over switching providers
+		if solvable.Priority() == -1 {
+			for _, candidate := range solvables[1:] {
+				if candidate.Name() != solvable.Name() {
+					continue
+				}
+
+				if candidate.Version().GT(solvable.Version()) {
+					return candidate
+				}
+
+				break
 			}
 		}
 
@@ -75,7 +86,159 @@ func (i *InstalledPolicy) PruneProvides(solvables Solvables) Solvables {
 }
 
 func (i *InstalledPolicy) SelectRequest(solvables Solvables) Solvable {
-	sort.Sort(solvables)
+	return preferInstalled(solvables)
+}
+
+// SelectSolution prefers the solution keeping the most installed packages, earlier solutions win ties
+func (i *InstalledPolicy) SelectSolution(solutions Solutions) *Solution {
+	selected := 0
+	kept := -1
+
+	for index := range solutions {
+		count := 0
+		for _, op := range solutions[index].Operations() {
+			if op.Operation == "noop" {
+				count++
+			}
+		}
+
+		if count > kept {
+			selected = index
+			kept = count
+		}
+	}
+
+	solution := solutions[selected]
+	return &solution
+}
+
+// MinimalPolicy keeps installed versions wherever possible and selects the solution with the fewest changes
+func (m *MinimalPolicy) PruneProvides(solvables Solvables) Solvables {
+	return pruneFrozen(solvables)
+}
+
+func (m *MinimalPolicy) SelectRequest(solvables Solvables) Solvable {
+	return preferInstalled(solvables)
+}
+
+func (m *MinimalPolicy) SelectSolution(solutions Solutions) *Solution {
+	selected := 0
+	changes := -1
+
+	for index := range solutions {
+		count := 0
+		for _, op := range solutions[index].Operations() {
+			if op.Operation != "noop" {
+				count++
+			}
+		}
+
+		if changes == -1 || count < changes {
+			selected = index
+			changes = count
+		}
+	}
+
+	solution := solutions[selected]
+	return &solution
+}
+
+// NewestPolicy selects the newest available version regardless of what is installed
+func (n *NewestPolicy) PruneProvides(solvables Solvables) Solvables {
+	return pruneFrozen(solvables)
+}
+
+func (n *NewestPolicy) SelectRequest(solvables Solvables) Solvable {
+	sort.Sort(candidates(solvables))
+
+	if len(solvables) == 0 || solvables[0].Priority() == -2 {
+		return first(solvables)
+	}
+
+	sort.SliceStable(solvables, func(i, j int) bool {
+		return solvables[i].Version().GT(solvables[j].Version())
+	})
+
+	return solvables[0]
+}
+
+func (n *NewestPolicy) SelectSolution(solutions Solutions) *Solution {
+	solution := solutions[0]
+	return &solution
+}
+
+// LowestPolicy selects the lowest version satisfying each requirement, useful for testing version constraints
+func (l *LowestPolicy) PruneProvides(solvables Solvables) Solvables {
+	return pruneFrozen(solvables)
+}
+
+func (l *LowestPolicy) SelectRequest(solvables Solvables) Solvable {
+	sort.Sort(candidates(solvables))
+
+	if len(solvables) == 0 || solvables[0].Priority() == -2 {
+		return first(solvables)
+	}
+
+	sort.SliceStable(solvables, func(i, j int) bool {
+		return solvables[i].Version().LT(solvables[j].Version())
+	})
+
+	return solvables[0]
+}
+
+// SelectSolution compares the versions solutions install, a solution replaces the selected one when
+// more of the packages both contain are at a lower version than at a higher one, earlier solutions win ties
+func (l *LowestPolicy) SelectSolution(solutions Solutions) *Solution {
+	selected := 0
+
+	for index := 1; index < len(solutions); index++ {
+		lower := 0
+		higher := 0
+
+		for _, op := range solutions[index].Operations() {
+			if op.Operation == "remove" {
+				continue
+			}
+
+			current := solutions[selected].Get(op.Package.Name())
+			if current == nil {
+				continue
+			}
+
+			if op.Package.Version().LT(current.Version()) {
+				lower++
+			} else if op.Package.Version().GT(current.Version()) {
+				higher++
+			}
+		}
+
+		if lower > higher {
+			selected = index
+		}
+	}
+
+	solution := solutions[selected]
+	return &solution
+}
+
+// PinnedPolicy behaves like UpdatedPolicy but never falls back to a lower priority repo
+// once a higher priority repo carries a package
+func (p *PinnedPolicy) PruneProvides(solvables Solvables) Solvables {
+	return pruneFrozen(pin(solvables))
+}
+
+func (p *PinnedPolicy) SelectRequest(solvables Solvables) Solvable {
+	return (&UpdatedPolicy{}).SelectRequest(pin(solvables))
+}
+
+func (p *PinnedPolicy) SelectSolution(solutions Solutions) *Solution {
+	solution := solutions[0]
+	return &solution
+}
+
+// preferInstalled selects the installed candidate if there is one, otherwise the best repo candidate
+func preferInstalled(solvables Solvables) Solvable {
+	sort.Sort(candidates(solvables))
 
 	for _, solvable := range solvables {
 		if solvable.Priority() <= -1 {
@@ -83,6 +246,10 @@ func (i *InstalledPolicy) SelectRequest(solvables Solvables) Solvable {
 		}
 	}
 
+	return first(solvables)
+}
+
+func first(solvables Solvables) Solvable {
 	for _, solvable := range solvables {
 		return solvable
 	}
@@ -90,6 +257,71 @@ func (i *InstalledPolicy) SelectRequest(solvables Solvables) Solvable {
 	return nil
 }
 
-func (i *InstalledPolicy) SelectSolution(solutions Solutions) *Solution {
-	return nil
+// pin drops repo candidates whose priority is lower than the best repo offering the same package
+func pin(solvables Solvables) Solvables {
+	var pinned Solvables
+	best := make(map[string]int)
+
+	for _, solvable := range solvables {
+		if solvable.Priority() < 0 {
+			continue
+		}
+
+		if priority, ok := best[solvable.Name()]; !ok || solvable.Priority() < priority {
+			best[solvable.Name()] = solvable.Priority()
+		}
+	}
+
+	for _, solvable := range solvables {
+		if solvable.Priority() < 0 || solvable.Priority() == best[solvable.Name()] {
+			pinned = append(pinned, solvable)
+		}
+	}
+
+	return pinned
+}
+
+// pruneFrozen restricts candidates to the frozen entry if there is one
+func pruneFrozen(solvables Solvables) Solvables {
+	if len(solvables) == 0 {
+		return solvables
+	}
+
+	sort.Sort(candidates(solvables))
+
+	if solvables[0].Priority() == -2 {
+		return Solvables{solvables[0]}
+	}
+
+	return solvables
+}
+
+// candidates orders solvables that may have different names, as is the case for
+// virtual packages, by repo priority then version
+type candidates Solvables
+
+func (slice candidates) Len() int {
+	return len(slice)
+}
+
+func (slice candidates) Less(i, j int) bool {
+	if slice[i].Priority() < slice[j].Priority() {
+		return true
+	}
+	if slice[i].Priority() > slice[j].Priority() {
+		return false
+	}
+
+	if slice[i].Version().GT(slice[j].Version()) {
+		return true
+	}
+	if slice[i].Version().LT(slice[j].Version()) {
+		return false
+	}
+
+	return slice[i].Name() < slice[j].Name()
+}
+
+func (slice candidates) Swap(i, j int) {
+	slice[i], slice[j] = slice[j], slice[i]
 }
@@ -0,0 +1,206 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zps
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testPolicies = []string{"updated", "installed", "minimal-change", "newest", "lowest-satisfying", "repo-pinned"}
+
+func TestNewPolicy(t *testing.T) {
+	for _, method := range testPolicies {
+		if NewPolicy(method) == nil {
+			t.Errorf("%s: no policy", method)
+		}
+	}
+
+	if NewPolicy("bogus") != nil {
+		t.Error("bogus: expected no policy")
+	}
+}
+
+func TestPolicySelectRequest(t *testing.T) {
+	// Installed packages carry priority -1, frozen ones -2, repos count up from 0
+	tests := []struct {
+		name     string
+		frozen   bool
+		expected map[string]string
+	}{
+		{
+			name: "installed",
+			expected: map[string]string{
+				"updated":           "1.1.0",
+				"installed":         "1.0.0",
+				"minimal-change":    "1.0.0",
+				"newest":            "1.2.0",
+				"lowest-satisfying": "1.0.0",
+				"repo-pinned":       "1.1.0",
+			},
+		},
+		{
+			name:   "frozen",
+			frozen: true,
+			expected: map[string]string{
+				"updated":           "1.0.0",
+				"installed":         "1.0.0",
+				"minimal-change":    "1.0.0",
+				"newest":            "1.0.0",
+				"lowest-satisfying": "1.0.0",
+				"repo-pinned":       "1.0.0",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		for _, method := range testPolicies {
+			t.Run(test.name+" "+method, func(t *testing.T) {
+				installed := -1
+				if test.frozen {
+					installed = -2
+				}
+
+				solvables := Solvables{
+					testPkg(t, "pkg", "1.2.0", 10),
+					testPkg(t, "pkg", "1.0.0", installed),
+					testPkg(t, "pkg", "1.1.0", 0),
+				}
+
+				policy := NewPolicy(method)
+				selected := policy.SelectRequest(policy.PruneProvides(solvables))
+				if selected == nil {
+					t.Fatal("nothing selected")
+				}
+
+				if selected.Version().Short() != test.expected[method] {
+					t.Errorf("got %s, want %s", selected.Version().Short(), test.expected[method])
+				}
+			})
+		}
+	}
+}
+
+func TestPolicySelectSolution(t *testing.T) {
+	solutions := Solutions{
+		testSolution(t, "install x", "install y"),
+		testSolution(t, "noop a", "install y", "install z"),
+		testSolution(t, "noop a", "noop b", "remove c"),
+		testSolution(t, "noop a", "install y"),
+	}
+
+	tests := []struct {
+		method    string
+		solutions Solutions
+		expected  string
+	}{
+		{"updated", solutions, "x y"},
+		{"installed", solutions, "a b"},
+		{"installed", Solutions{solutions[3], solutions[1]}, "a y"},
+		{"minimal-change", solutions, "a b"},
+		{"newest", solutions, "x y"},
+		{"lowest-satisfying", solutions, "x y"},
+		{"repo-pinned", solutions, "x y"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method, func(t *testing.T) {
+			selected := NewPolicy(test.method).SelectSolution(test.solutions)
+			if selected == nil {
+				t.Fatal("no solution selected")
+			}
+
+			names := strings.Join(selected.Names(), " ")
+			if names != test.expected {
+				t.Errorf("got %q, want %q", names, test.expected)
+			}
+		})
+	}
+}
+
+func TestLowestPolicySelectSolution(t *testing.T) {
+	tests := []struct {
+		name      string
+		solutions Solutions
+		expected  int
+	}{
+		{
+			name: "lower version",
+			solutions: Solutions{
+				testSolution(t, "install app 1.0.0", "install lib 2.0.0"),
+				testSolution(t, "install app 1.0.0", "install lib 1.0.0"),
+			},
+			expected: 1,
+		},
+		{
+			name: "more packages lower",
+			solutions: Solutions{
+				testSolution(t, "install app 1.0.0", "install lib 1.0.0", "install util 2.0.0"),
+				testSolution(t, "install app 0.9.0", "install lib 1.1.0", "install util 1.0.0"),
+			},
+			expected: 1,
+		},
+		{
+			name: "tie keeps earlier",
+			solutions: Solutions{
+				testSolution(t, "install app 1.0.0", "install lib 2.0.0"),
+				testSolution(t, "install app 2.0.0", "install lib 1.0.0"),
+			},
+			expected: 0,
+		},
+		{
+			name: "removals ignored",
+			solutions: Solutions{
+				testSolution(t, "install app 1.0.0", "remove lib 2.0.0"),
+				testSolution(t, "install app 1.0.0", "noop lib 3.0.0"),
+			},
+			expected: 0,
+		},
+		{
+			name: "installed version counts",
+			solutions: Solutions{
+				testSolution(t, "install app 1.0.0", "install lib 2.0.0"),
+				testSolution(t, "install app 1.0.0", "noop lib 1.5.0"),
+				testSolution(t, "install app 1.0.0", "install lib 1.8.0"),
+			},
+			expected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected := NewPolicy("lowest-satisfying").SelectSolution(test.solutions)
+
+			if !reflect.DeepEqual(selected.Operations(), test.solutions[test.expected].Operations()) {
+				t.Errorf("got %v, want solution %d", selected.Operations(), test.expected)
+			}
+		})
+	}
+}
+
+// testSolution builds a solution from "operation name [version]" entries, versions default to 1.0.0
+func testSolution(t *testing.T, operations ...string) Solution {
+	solution := NewSolution()
+
+	for _, operation := range operations {
+		split := strings.Split(operation, " ")
+
+		version := "1.0.0"
+		if len(split) > 2 {
+			version = split[2]
+		}
+
+		solution.AddOperation(NewOperation(split[0], testPkg(t, split[1], version, 0)))
+	}
+
+	return *solution
+}
@@ -11,8 +11,11 @@
 package zps
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+
+	"github.com/blang/semver"
 )
 
 type Requirement struct {
@@ -20,6 +23,14 @@ type Requirement struct {
 	Method    string
 	Operation int
 	Version   *Version
+
+	// Additional bounds for range requirements, all must hold
+	Constraints []*Constraint `json:",omitempty"`
+}
+
+type Constraint struct {
+	Operation int
+	Version   *Version
 }
 
 func NewRequirement(name string, version *Version) *Requirement {
@@ -30,7 +41,7 @@ func NewRequirementFromSimpleString(id string) (*Requirement, error) {
 	requirement := &Requirement{}
 	requirement.Method = "depends"
 
-	split := strings.Split(id, "@")
+	split := strings.SplitN(id, "@", 2)
 
 	if len(split) < 2 {
 		requirement.Name = id
@@ -39,8 +50,16 @@ func NewRequirementFromSimpleString(id string) (*Requirement, error) {
 
 	requirement.Name = split[0]
 
-	version := &Version{}
-	err := version.Parse(split[1])
+	if IsRangeExpression(split[1]) {
+		err := requirement.Range(split[1])
+		if err != nil {
+			return nil, err
+		}
+
+		return requirement, nil
+	}
+
+	version, err := parseBound(split[1])
 	if err != nil {
 		return nil, err
 	}
@@ -54,6 +73,117 @@ func NewRequirementFromSimpleString(id string) (*Requirement, error) {
 	}
 }
 
+// NewRequirementFromExpression builds a requirement from an operation name and version as found in
+// Zpkgfile Requirement and Imagefile Package blocks, the version may instead be a range expression
+func NewRequirementFromExpression(name string, operation string, version string) (*Requirement, error) {
+	requirement := NewRequirement(name, nil)
+
+	if IsRangeExpression(version) {
+		if operation != "" && operation != "RANGE" {
+			return nil, fmt.Errorf("zps.Requirement: %s range expression does not take operation %s", name, operation)
+		}
+
+		err := requirement.Range(version)
+		if err != nil {
+			return nil, err
+		}
+
+		return requirement, nil
+	}
+
+	if version == "" {
+		if operation != "" && operation != "ANY" {
+			return nil, fmt.Errorf("zps.Requirement: %s operation %s requires a version", name, operation)
+		}
+
+		return requirement.ANY(), nil
+	}
+
+	bound, err := parseBound(version)
+	if err != nil {
+		return nil, err
+	}
+
+	requirement.Version = bound
+
+	if operation == "" {
+		if bound.Timestamp.IsZero() {
+			return requirement.EQ(), nil
+		}
+
+		return requirement.EXQ(), nil
+	}
+
+	if _, ok := opCode(operation); !ok {
+		return nil, fmt.Errorf("zps.Requirement: %s unsupported operation %s", name, operation)
+	}
+
+	return requirement.Op(operation), nil
+}
+
+// IsRangeExpression reports whether a version string starts with an operator, eg ">=1.2,<2.0" or "^1.4"
+func IsRangeExpression(expression string) bool {
+	return strings.IndexAny(strings.TrimSpace(expression), "<>=!^~") == 0
+}
+
+// Range parses a comma separated list of bounds, supported operators are
+// ===, ==, =, !=, >=, <=, >, <, ^ and ~
+func (r *Requirement) Range(expression string) error {
+	r.Version = nil
+	r.Constraints = nil
+
+	for _, term := range strings.Split(expression, ",") {
+		term = strings.TrimSpace(term)
+
+		rest := strings.TrimLeft(term, "<>=!^~")
+		operator := term[:len(term)-len(rest)]
+		value := strings.TrimSpace(rest)
+
+		if value == "" {
+			return fmt.Errorf("zps.Requirement: missing version in range term %q", term)
+		}
+
+		bound, err := parseBound(value)
+		if err != nil {
+			return err
+		}
+
+		switch operator {
+		case "^":
+			upper := semver.Version{Major: bound.Semver.Major + 1}
+			if bound.Semver.Major == 0 && bound.Semver.Minor > 0 {
+				upper = semver.Version{Minor: bound.Semver.Minor + 1}
+			} else if bound.Semver.Major == 0 {
+				upper = semver.Version{Patch: bound.Semver.Patch + 1}
+			}
+
+			r.bound(1, bound)
+			r.bound(-2, &Version{Semver: upper})
+		case "~":
+			upper := semver.Version{Major: bound.Semver.Major, Minor: bound.Semver.Minor + 1}
+			if !strings.Contains(value, ".") {
+				upper = semver.Version{Major: bound.Semver.Major + 1}
+			}
+
+			r.bound(1, bound)
+			r.bound(-2, &Version{Semver: upper})
+		default:
+			code, ok := symbolCode(operator)
+			if !ok {
+				return fmt.Errorf("zps.Requirement: unsupported operator %q", operator)
+			}
+
+			r.bound(code, bound)
+		}
+	}
+
+	if r.Version == nil {
+		return errors.New("zps.Requirement: empty range expression")
+	}
+
+	return nil
+}
+
 func (r *Requirement) Depends() *Requirement {
 	r.Method = "depends"
 	return r
@@ -79,48 +209,75 @@ func (r *Requirement) GTE() *Requirement {
 	return r
 }
 
+func (r *Requirement) GT() *Requirement {
+	r.Operation = 4
+	return r
+}
+
 func (r *Requirement) LTE() *Requirement {
 	r.Operation = -1
 	return r
 }
 
+func (r *Requirement) LT() *Requirement {
+	r.Operation = -2
+	return r
+}
+
 func (r *Requirement) EQ() *Requirement {
 	r.Operation = 0
 	return r
 }
 
+func (r *Requirement) NEQ() *Requirement {
+	r.Operation = 5
+	return r
+}
+
 func (r *Requirement) EXQ() *Requirement {
 	r.Operation = 2
 	return r
 }
 
 func (r *Requirement) Op(op string) *Requirement {
-	switch op {
-	case "ANY":
-		return r.ANY()
-	case "GTE":
-		return r.GTE()
-	case "LTE":
-		return r.LTE()
-	case "EQ":
-		return r.EQ()
-	case "EXQ":
-		return r.EXQ()
+	if code, ok := opCode(op); ok {
+		r.Operation = code
 	}
 
 	return r
 }
 
+// Matches reports whether version meets the requirement operation and any additional constraints
+func (r *Requirement) Matches(version *Version) bool {
+	if !match(r.Operation, r.Version, version) {
+		return false
+	}
+
+	for _, constraint := range r.Constraints {
+		if !match(constraint.Operation, constraint.Version, version) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (r *Requirement) OpString() string {
 	switch r.Operation {
 	case 3:
 		return "ANY"
 	case 1:
 		return "GTE"
+	case 4:
+		return "GT"
 	case -1:
 		return "LTE"
+	case -2:
+		return "LT"
 	case 0:
 		return "EQ"
+	case 5:
+		return "NEQ"
 	case 2:
 		return "EXQ"
 	}
@@ -129,34 +286,151 @@ func (r *Requirement) OpString() string {
 }
 
 func (r *Requirement) OpInt(op string) int {
-	switch op {
-	case "ANY":
-		return 3
-	case "GTE":
-		return 1
-	case "LTE":
-		return -1
-	case "EQ":
-		return 0
-	case "EXQ":
-		return 2
+	if code, ok := opCode(op); ok {
+		return code
 	}
 
 	return 3
 }
 
 func (r *Requirement) String() string {
-	switch r.Operation {
-	case 3:
+	if r.Operation == 3 {
 		return fmt.Sprint(r.Name, " == ", "*")
+	}
+
+	bounds := []string{boundString(r.Operation, r.Version)}
+	for _, constraint := range r.Constraints {
+		bounds = append(bounds, boundString(constraint.Operation, constraint.Version))
+	}
+
+	return fmt.Sprint(r.Name, " ", strings.Join(bounds, ", "))
+}
+
+// bound sets the primary operation first, further bounds become constraints
+func (r *Requirement) bound(operation int, version *Version) {
+	if r.Version == nil {
+		r.Operation = operation
+		r.Version = version
+		return
+	}
+
+	r.Constraints = append(r.Constraints, &Constraint{operation, version})
+}
+
+func boundString(operation int, version *Version) string {
+	switch operation {
 	case 2:
-		return fmt.Sprint(r.Name, " === ", r.Version.String())
+		return fmt.Sprint("=== ", version.String())
 	case 1:
-		return fmt.Sprint(r.Name, " >= ", r.Version.Short())
+		return fmt.Sprint(">= ", version.Short())
+	case 4:
+		return fmt.Sprint("> ", version.Short())
 	case 0:
-		return fmt.Sprint(r.Name, " == ", r.Version.Short())
+		return fmt.Sprint("== ", version.Short())
+	case 5:
+		return fmt.Sprint("!= ", version.Short())
 	case -1:
-		return fmt.Sprint(r.Name, " <= ", r.Version.Short())
+		return fmt.Sprint("<= ", version.Short())
+	case -2:
+		return fmt.Sprint("< ", version.Short())
 	}
+
 	return ""
 }
+
+func match(operation int, bound *Version, version *Version) bool {
+	switch operation {
+	case 3:
+		return true
+	case 2:
+		return version.EXQ(bound)
+	}
+
+	// Bounds without a timestamp only consider semver
+	if bound.Timestamp.IsZero() {
+		version = &Version{Semver: version.Semver}
+	}
+
+	compare := version.Compare(bound)
+	if compare == 2 {
+		compare = 0
+	}
+
+	switch operation {
+	case 1:
+		return compare >= 0
+	case 4:
+		return compare > 0
+	case 0:
+		return compare == 0
+	case 5:
+		return compare != 0
+	case -1:
+		return compare <= 0
+	case -2:
+		return compare < 0
+	}
+
+	return false
+}
+
+func opCode(op string) (int, bool) {
+	switch op {
+	case "ANY":
+		return 3, true
+	case "GTE":
+		return 1, true
+	case "GT":
+		return 4, true
+	case "LTE":
+		return -1, true
+	case "LT":
+		return -2, true
+	case "EQ":
+		return 0, true
+	case "NEQ":
+		return 5, true
+	case "EXQ":
+		return 2, true
+	}
+
+	return 0, false
+}
+
+func symbolCode(symbol string) (int, bool) {
+	switch symbol {
+	case "===":
+		return 2, true
+	case "==", "=":
+		return 0, true
+	case "!=":
+		return 5, true
+	case ">=":
+		return 1, true
+	case ">":
+		return 4, true
+	case "<=":
+		return -1, true
+	case "<":
+		return -2, true
+	}
+
+	return 0, false
+}
+
+// parseBound accepts full zps versions or partial semver such as 1.4
+func parseBound(version string) (*Version, error) {
+	bound := &Version{}
+
+	if strings.Contains(version, ":") {
+		return bound, bound.Parse(version)
+	}
+
+	var err error
+	bound.Semver, err = semver.ParseTolerant(version)
+	if err != nil {
+		return nil, fmt.Errorf("zps.Version: error parsing version %s", version)
+	}
+
+	return bound, nil
+}
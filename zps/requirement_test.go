@@ -0,0 +1,121 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zps
+
+import (
+	"testing"
+)
+
+func TestRequirementRange(t *testing.T) {
+	tests := []struct {
+		expression string
+		matches    []string
+		rejects    []string
+	}{
+		{">=1.2,<2.0", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0"}},
+		{">1.2, <=1.4", []string{"1.2.1", "1.4.0"}, []string{"1.2.0", "1.4.1"}},
+		{"!=1.3.0", []string{"1.2.9", "1.3.1"}, []string{"1.3.0"}},
+		{"==1.3", []string{"1.3.0"}, []string{"1.3.1"}},
+		{"^1.4.2", []string{"1.4.2", "1.9.0"}, []string{"1.4.1", "2.0.0"}},
+		{"^0.4.2", []string{"0.4.2", "0.4.9"}, []string{"0.4.1", "0.5.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.2", "0.0.4"}},
+		{"~1.4.2", []string{"1.4.2", "1.4.9"}, []string{"1.4.1", "1.5.0"}},
+		{"~1.4", []string{"1.4.0", "1.4.9"}, []string{"1.3.9", "1.5.0"}},
+		{"~1", []string{"1.0.0", "1.9.0"}, []string{"0.9.0", "2.0.0"}},
+		{"^1.4, !=1.5.0", []string{"1.4.0", "1.5.1"}, []string{"1.5.0", "2.0.0"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expression, func(t *testing.T) {
+			requirement, err := NewRequirementFromSimpleString("pkg@" + test.expression)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, version := range test.matches {
+				if !requirement.Matches(testVersion(t, version)) {
+					t.Errorf("%s should match %s", requirement, version)
+				}
+			}
+
+			for _, version := range test.rejects {
+				if requirement.Matches(testVersion(t, version)) {
+					t.Errorf("%s should not match %s", requirement, version)
+				}
+			}
+		})
+	}
+}
+
+func TestRequirementRangeErrors(t *testing.T) {
+	tests := []string{">=", ">=1.2,", "=>1.2", "<>1.2", "^x.y"}
+
+	for _, expression := range tests {
+		t.Run(expression, func(t *testing.T) {
+			requirement := NewRequirement("pkg", nil)
+
+			if err := requirement.Range(expression); err == nil {
+				t.Errorf("%q should not parse, got %s", expression, requirement)
+			}
+		})
+	}
+}
+
+func TestRequirementFromExpression(t *testing.T) {
+	tests := []struct {
+		operation string
+		version   string
+		expected  string
+		fails     bool
+	}{
+		{"", "", "pkg == *", false},
+		{"ANY", "", "pkg == *", false},
+		{"GTE", "", "", true},
+		{"", "1.2.0", "pkg == 1.2.0", false},
+		{"GTE", "1.2", "pkg >= 1.2.0", false},
+		{"LT", "2.0.0", "pkg < 2.0.0", false},
+		{"BOGUS", "1.2.0", "", true},
+		{"", ">=1.2,<2.0", "pkg >= 1.2.0, < 2.0.0", false},
+		{"RANGE", "^1.4", "pkg >= 1.4.0, < 2.0.0", false},
+		{"GTE", "^1.4", "", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.operation+" "+test.version, func(t *testing.T) {
+			requirement, err := NewRequirementFromExpression("pkg", test.operation, test.version)
+			if test.fails {
+				if err == nil {
+					t.Errorf("expected an error, got %s", requirement)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if requirement.String() != test.expected {
+				t.Errorf("got %q, want %q", requirement.String(), test.expected)
+			}
+		})
+	}
+}
+
+func testVersion(t *testing.T, version string) *Version {
+	t.Helper()
+
+	parsed := &Version{}
+	if err := parsed.Parse(version); err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
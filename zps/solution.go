@@ -103,13 +103,19 @@ func (s *Solution) Graph() ([]*Operation, error) {
 
 	for _, op := range s.operations {
 		for _, req := range op.Package.Requirements() {
+			if req.Method != "depends" {
+				continue
+			}
+
 			switch op.Operation {
 			case "install", "noop":
-				edge := s.installGraph.NewEdge(s.installMap[req.Name].Node, op.Node)
-				s.installGraph.SetEdge(edge)
+				if dep := s.provider(s.installMap, req); dep != nil && dep != op {
+					edge := s.installGraph.NewEdge(dep.Node, op.Node)
+					s.installGraph.SetEdge(edge)
+				}
 			case "remove":
-				if s.removeMap[req.Name] != nil {
-					edge := s.removeGraph.NewEdge(s.removeMap[req.Name].Node, op.Node)
+				if dep := s.provider(s.removeMap, req); dep != nil && dep != op {
+					edge := s.removeGraph.NewEdge(dep.Node, op.Node)
 					s.removeGraph.SetEdge(edge)
 				}
 			}
@@ -142,6 +148,21 @@ func (s *Solution) Graph() ([]*Operation, error) {
 	return operations, nil
 }
 
+// provider resolves a requirement to an operation by name, falling back to a package providing it
+func (s *Solution) provider(ops map[string]*Operation, req *Requirement) *Operation {
+	if ops[req.Name] != nil {
+		return ops[req.Name]
+	}
+
+	for _, op := range ops {
+		if op.Package.Satisfies(req) {
+			return op
+		}
+	}
+
+	return nil
+}
+
 type Solutions []Solution
 
 func (slice Solutions) Len() int {
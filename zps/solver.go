@@ -11,7 +11,6 @@
 package zps
 
 import (
-	"errors"
 	"sort"
 
 	"github.com/fezz-io/sat"
@@ -26,10 +25,11 @@ type Solver struct {
 	solutions    Solutions
 	seen         map[string]bool
 	solver       *sat.Solver
+	clauses      []*clause
 }
 
 func NewSolver(pool *Pool, policy Policy) *Solver {
-	solver := &Solver{pool, policy, nil, nil, nil, make(map[string]bool), sat.NewSolver()}
+	solver := &Solver{pool, policy, nil, nil, nil, make(map[string]bool), sat.NewSolver(), nil}
 	return solver
 }
 
@@ -42,7 +42,7 @@ func (s *Solver) Solve(request *Request) (*Solution, error) {
 
 	satisfiable, s.satSolutions = s.solver.Satisfiable()
 	if satisfiable == false {
-		return nil, errors.New("zps.solver: No solution for requested jobs")
+		return nil, s.explain()
 	}
 
 	s.generateSolutions()
@@ -72,7 +72,7 @@ func (s *Solver) addClauses() {
 
 			if candidate != nil {
 				clause = sat.NewVariable(candidate.Id())
-				s.solver.AddClause(clause)
+				s.addClause(&Reason{Kind: "install", Solvable: candidate}, clause)
 				s.addReqClauses(candidate)
 			}
 		case "remove":
@@ -81,7 +81,7 @@ func (s *Solver) addClauses() {
 
 			if candidate != nil {
 				clause = sat.NewVariable(candidate.Id()).Not()
-				s.solver.AddClause(clause)
+				s.addClause(&Reason{Kind: "remove", Solvable: candidate}, clause)
 				s.addRmClauses(candidate)
 			}
 		default:
@@ -90,6 +90,12 @@ func (s *Solver) addClauses() {
 	}
 }
 
+// addClause records the reason for a clause so unsatisfiable requests can be explained
+func (s *Solver) addClause(reason *Reason, literals ...sat.LiteralEncoder) {
+	s.clauses = append(s.clauses, &clause{literals, reason})
+	s.solver.AddClause(literals...)
+}
+
 func (s *Solver) addReqClauses(solvable Solvable) {
 	for _, req := range solvable.Requirements() {
 		// Continue if a requirement references itself
@@ -116,13 +122,17 @@ func (s *Solver) addReqClauses(solvable Solvable) {
 				s.addReqClauses(candidate)
 			}
 
-			s.solver.AddClause(clause...)
+			s.addClause(&Reason{Kind: "depends", Solvable: solvable, Requirement: req, Candidates: provides}, clause...)
 
 			for index, provided := range provides {
 				current := index + 1
 				for current <= len(provides)-1 {
 					if provided.Id() != provides[current].Id() {
-						s.solver.AddClause(sat.NewVariable(provided.Id()).Not(), sat.NewVariable(provides[current].Id()).Not())
+						s.addClause(
+							&Reason{Kind: "single", Requirement: req, Candidates: Solvables{provided, provides[current]}},
+							sat.NewVariable(provided.Id()).Not(),
+							sat.NewVariable(provides[current].Id()).Not(),
+						)
 					}
 					current++
 				}
@@ -130,7 +140,11 @@ func (s *Solver) addReqClauses(solvable Solvable) {
 
 		case "conflicts":
 			for _, candidate := range s.policy.PruneProvides(s.pool.WhatProvides(req)) {
-				s.solver.AddClause(sat.NewVariable(solvable.Id()).Not(), sat.NewVariable(candidate.Id()).Not())
+				s.addClause(
+					&Reason{Kind: "conflicts", Solvable: solvable, Requirement: req, Candidates: Solvables{candidate}},
+					sat.NewVariable(solvable.Id()).Not(),
+					sat.NewVariable(candidate.Id()).Not(),
+				)
 			}
 		default:
 			continue
@@ -139,12 +153,33 @@ func (s *Solver) addReqClauses(solvable Solvable) {
 }
 
 func (s *Solver) addRmClauses(solvable Solvable) {
-	for _, dep := range s.pool.WhatDepends(solvable.Name()) {
-		clause := sat.NewVariable(dep.Id()).Not()
-		s.solver.AddClause(clause)
-		// recurse
-		s.addRmClauses(dep)
+	names := []string{solvable.Name()}
+
+	// Dependents of a virtual package are only affected if no other installed package provides it
+	for _, req := range solvable.Requirements() {
+		if req.Method == "provides" && !s.providedElsewhere(solvable, req) {
+			names = append(names, req.Name)
+		}
 	}
+
+	for _, name := range names {
+		for _, dep := range s.pool.WhatDepends(name) {
+			clause := sat.NewVariable(dep.Id()).Not()
+			s.addClause(&Reason{Kind: "dependent", Solvable: dep, Requirement: NewRequirement(name, nil).ANY()}, clause)
+			// recurse
+			s.addRmClauses(dep)
+		}
+	}
+}
+
+func (s *Solver) providedElsewhere(solvable Solvable, provide *Requirement) bool {
+	for _, candidate := range s.pool.WhatProvides(NewRequirement(provide.Name, nil).ANY()) {
+		if candidate.Priority() <= -1 && candidate.Name() != solvable.Name() {
+			return true
+		}
+	}
+
+	return false
 }
 
 func (s *Solver) generateSolutions() {
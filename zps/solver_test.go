@@ -0,0 +1,136 @@
+/*
+ * This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/.
+ */
+
+/*
+ * Copyright 2021 Zachary Schneider
+ */
+
+package zps
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSolveVirtualProvides(t *testing.T) {
+	requirement := func(name string, method string, operation string, version string) *Requirement {
+		req, err := NewRequirementFromExpression(name, operation, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Method = method
+
+		return req
+	}
+
+	pkg := func(name string, version string, reqs ...*Requirement) *Pkg {
+		pkg, err := NewPkg(name, version, "test", reqs, "x86_64", "linux", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return pkg
+	}
+
+	nginx := func() *Pkg { return pkg("nginx", "1.0.0", requirement("http-server", "provides", "EQ", "1.0")) }
+	apache := func() *Pkg { return pkg("apache", "2.0.0", requirement("http-server", "provides", "EQ", "2.0")) }
+	lighttpd := func() *Pkg { return pkg("lighttpd", "3.0.0", requirement("http-server", "provides", "", "")) }
+
+	tests := []struct {
+		name     string
+		image    Solvables
+		repos    []Solvables
+		install  *Requirement
+		expected []string
+		unsat    bool
+	}{
+		{
+			name:     "versioned dependency",
+			repos:    []Solvables{{nginx(), apache(), pkg("app", "1.0.0", requirement("http-server", "depends", "GTE", "2.0"))}},
+			install:  requirement("app", "depends", "", ""),
+			expected: []string{"install apache", "install app"},
+		},
+		{
+			name:     "versioned request",
+			repos:    []Solvables{{nginx(), apache()}},
+			install:  requirement("http-server", "depends", "LT", "2.0"),
+			expected: []string{"install nginx"},
+		},
+		{
+			name:     "provider by version",
+			repos:    []Solvables{{nginx(), apache()}},
+			install:  requirement("http-server", "depends", "", ""),
+			expected: []string{"install apache"},
+		},
+		{
+			name:     "provider by repo priority",
+			repos:    []Solvables{{nginx()}, {apache()}},
+			install:  requirement("http-server", "depends", "", ""),
+			expected: []string{"install nginx"},
+		},
+		{
+			name:     "installed provider",
+			image:    Solvables{nginx()},
+			repos:    []Solvables{{apache()}},
+			install:  requirement("http-server", "depends", "", ""),
+			expected: []string{"noop nginx"},
+		},
+		{
+			name:    "unversioned provide",
+			repos:   []Solvables{{lighttpd(), pkg("app", "1.0.0", requirement("http-server", "depends", "GTE", "1.0"))}},
+			install: requirement("app", "depends", "", ""),
+			unsat:   true,
+		},
+		{
+			name:     "unversioned dependency",
+			repos:    []Solvables{{lighttpd(), pkg("app", "1.0.0", requirement("http-server", "depends", "", ""))}},
+			install:  requirement("app", "depends", "", ""),
+			expected: []string{"install app", "install lighttpd"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var repos []*Repo
+			for priority, solvables := range test.repos {
+				repos = append(repos, NewRepo("test", priority, true, nil, solvables))
+			}
+
+			pool, err := NewPool(NewRepo("image", -1, true, nil, test.image), nil, repos...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := NewRequest()
+			request.Install(test.install)
+
+			solution, err := NewSolver(pool, NewPolicy("updated")).Solve(request)
+			if test.unsat {
+				if _, ok := err.(*UnsatisfiableError); !ok {
+					t.Errorf("expected an unsatisfiable error, got %v", err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var operations []string
+			for _, op := range solution.Operations() {
+				operations = append(operations, op.Operation+" "+op.Package.Name())
+			}
+
+			sort.Strings(operations)
+
+			if !reflect.DeepEqual(operations, test.expected) {
+				t.Errorf("got %v, want %v", operations, test.expected)
+			}
+		})
+	}
+}